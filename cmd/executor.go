@@ -1 +1,265 @@
 package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/highlight"
+)
+
+// Special lines of `goto`.
+const (
+	LineTop = 0
+	LineEnd = -1
+)
+
+// Target - the state commands are run against, implemented by the UI.
+type Target interface {
+	Filter(Filter)            // nil clears the filter
+	Search(*regexp.Regexp)    // nil clears the search
+	Goto(int)                 // 1 based line, `LineTop` or `LineEnd`
+	Save(string)              // path to write the lines shown into
+	Highlight(highlight.Rule) // adds a highlight rule
+	Set(string, any)          // option name and its parsed value, nil shows it
+	Columns([]string)         // fields shown in the table mode, nil for all
+	Sort(string, bool)        // field the rows are sorted by and if descending, "" for none
+}
+
+// Filter - decides which lines are shown.
+type Filter interface {
+	Match(string) bool // given the plain text of a record, its lines joined by `\n`
+	String() string
+}
+
+// Projector - a filter that also changes the lines shown.
+type Projector interface {
+	Project(string) (string, bool) // given the plain text of a line, `false` to show it as is
+}
+
+type Executor struct {
+	filters map[string]string // saved filters by name
+}
+
+// NewExecutor - creates an executor that knows of the saved `filters`.
+func NewExecutor(filters map[string]string) Executor {
+	return Executor{filters: filters}
+}
+
+// Execute - parses and runs the command `line` against the `target`. Returns
+// the message to show for it, empty when the target reports the outcome
+// itself.
+func (e Executor) Execute(line string, target Target) (string, error) {
+	command, err := Parse(line)
+	if err != nil || command == nil {
+		return "", err
+	}
+
+	switch command.Name {
+	case "filter":
+		return e.filter(command.Args, target)
+	case "search":
+		return e.search(command.Args, target)
+	case "save":
+		target.Save(command.Args[0])
+		return "", nil
+	case "goto":
+		return e.gotoLine(command.Args[0], target)
+	case "highlight":
+		return e.highlight(command.Args, target)
+	case "set":
+		return e.set(command.Args, target)
+	case "columns":
+		return e.columns(command.Args, target)
+	case "sort":
+		return e.sort(command.Args, target)
+	case "query":
+		return e.query(command.Raw, target)
+	}
+
+	return "", fmt.Errorf("command %q is not implemented", command.Name)
+}
+
+// ----------------------- PRIVATE
+
+func (e Executor) filter(args []string, target Target) (string, error) {
+	invert := len(args) > 0 && args[0] == "-v"
+	if invert {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		if invert {
+			return "", fmt.Errorf("usage: filter -v <regex>")
+		}
+		target.Filter(nil)
+		return "filter cleared", nil
+	}
+	if len(args) > 1 {
+		spec, _ := Lookup("filter")
+		return "", fmt.Errorf("usage: %s", spec.Usage)
+	}
+
+	pattern := args[0]
+	if name, ok := strings.CutPrefix(pattern, "@"); ok {
+		saved, found := e.filters[name]
+		if !found {
+			return "", fmt.Errorf("no saved filter %q", name)
+		}
+		pattern = saved
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q - %w", pattern, err)
+	}
+
+	// `^` and `$` match at every line of a record
+	multiline := regexp.MustCompile("(?m)" + pattern)
+	filter := regexFilter{re: re, lines: multiline, invert: invert}
+	target.Filter(filter)
+	return "filtering " + filter.String(), nil
+}
+
+func (e Executor) search(args []string, target Target) (string, error) {
+	if len(args) == 0 {
+		target.Search(nil)
+		return "search cleared", nil
+	}
+
+	re, err := regexp.Compile(args[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q - %w", args[0], err)
+	}
+
+	target.Search(re)
+	return "searching /" + re.String() + "/", nil
+}
+
+func (e Executor) gotoLine(arg string, target Target) (string, error) {
+	switch arg {
+	case "top":
+		target.Goto(LineTop)
+		return "at the top", nil
+	case "end":
+		target.Goto(LineEnd)
+		return "at the end", nil
+	}
+
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 {
+		return "", fmt.Errorf("invalid line %q", arg)
+	}
+
+	target.Goto(line)
+	return fmt.Sprintf("at line %d", line), nil
+}
+
+func (e Executor) highlight(args []string, target Target) (string, error) {
+	rule, err := highlight.NewRule(args[0], args[1:]...)
+	if err != nil {
+		return "", err
+	}
+
+	target.Highlight(rule)
+	return "highlighting " + rule.Source, nil
+}
+
+func (e Executor) set(args []string, target Target) (string, error) {
+	option, ok := LookupOption(args[0])
+	if !ok {
+		return "", fmt.Errorf("unknown option %q, expected one of %s", args[0], optionNames())
+	}
+
+	if len(args) == 1 {
+		target.Set(option.Name, nil)
+		return "", nil
+	}
+
+	value, err := option.Parse(args[1])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", option.Name, err)
+	}
+
+	target.Set(option.Name, value)
+	return fmt.Sprintf("%s = %v", option.Name, value), nil
+}
+
+func (e Executor) columns(args []string, target Target) (string, error) {
+	if len(args) == 0 {
+		target.Columns(nil)
+		return "showing all columns", nil
+	}
+
+	// columns can be given separated by commas as well
+	var fields []string
+	for _, arg := range args {
+		for _, field := range strings.Split(arg, ",") {
+			if len(field) > 0 {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	target.Columns(fields)
+	return "columns " + strings.Join(fields, ", "), nil
+}
+
+func (e Executor) sort(args []string, target Target) (string, error) {
+	if len(args) == 0 {
+		target.Sort("", false)
+		return "sort cleared", nil
+	}
+
+	descending := false
+	if len(args) == 2 {
+		switch args[1] {
+		case "asc":
+		case "desc":
+			descending = true
+		default:
+			return "", fmt.Errorf("expected asc or desc, got %q", args[1])
+		}
+	}
+
+	target.Sort(args[0], descending)
+	if descending {
+		return "sorting by " + args[0] + " descending", nil
+	}
+	return "sorting by " + args[0], nil
+}
+
+func (e Executor) query(source string, target Target) (string, error) {
+	if len(source) == 0 {
+		target.Filter(nil)
+		return "query cleared", nil
+	}
+
+	query, err := ParseQuery(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid query - %w", err)
+	}
+
+	target.Filter(query)
+	return "querying " + query.String(), nil
+}
+
+// regexFilter - shows the records matching the regex, or not matching it when
+// inverted.
+type regexFilter struct {
+	re     *regexp.Regexp // as typed, shown back to the user
+	lines  *regexp.Regexp // matching every line of a record
+	invert bool
+}
+
+func (f regexFilter) Match(record string) bool {
+	return f.lines.MatchString(record) != f.invert
+}
+
+func (f regexFilter) String() string {
+	if f.invert {
+		return "-v /" + f.re.String() + "/"
+	}
+	return "/" + f.re.String() + "/"
+}
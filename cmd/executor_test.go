@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/SpandanBG/logctrl/highlight"
+)
+
+// recordTarget - records the calls made to it as strings.
+type recordTarget struct {
+	calls  []string
+	filter Filter
+}
+
+func (r *recordTarget) Filter(f Filter) {
+	r.filter = f
+	if f == nil {
+		r.record("filter nil")
+		return
+	}
+	r.record("filter " + f.String())
+}
+
+func (r *recordTarget) Search(re *regexp.Regexp) {
+	if re == nil {
+		r.record("search nil")
+		return
+	}
+	r.record("search " + re.String())
+}
+
+func (r *recordTarget) Goto(line int)                 { r.record(fmt.Sprint("goto ", line)) }
+func (r *recordTarget) Save(path string)              { r.record("save " + path) }
+func (r *recordTarget) Highlight(rule highlight.Rule) { r.record("highlight " + rule.Source) }
+func (r *recordTarget) Set(name string, value any)    { r.record(fmt.Sprint("set ", name, " ", value)) }
+func (r *recordTarget) Columns(fields []string)       { r.record(fmt.Sprint("columns ", fields)) }
+func (r *recordTarget) Sort(field string, desc bool)  { r.record(fmt.Sprint("sort ", field, " ", desc)) }
+
+func (r *recordTarget) record(call string) {
+	r.calls = append(r.calls, call)
+}
+
+func Test_ExecuteTableDriven(t *testing.T) {
+	executor := NewExecutor(map[string]string{"errors": "ERROR|FATAL"})
+
+	for _, test := range []struct {
+		line     string
+		call     string // call made on the target, empty for none
+		expected string // message, or the error
+	}{
+		{"filter error", "filter /error/", "filtering /error/"},
+		{"filter -v debug", "filter -v /debug/", "filtering -v /debug/"},
+		{"filter @errors", "filter /ERROR|FATAL/", "filtering /ERROR|FATAL/"},
+		{"filter", "filter nil", "filter cleared"},
+		{"filter @nope", "", `no saved filter "nope"`},
+		{"filter -v", "", "usage: filter -v"},
+		{"filter foo bar", "", "usage: filter"},
+		{"filter -v a b", "", "usage: filter"},
+		{"filter (", "", `invalid pattern "("`},
+		{"search time.*", "search time.*", "searching /time.*/"},
+		{"search", "search nil", "search cleared"},
+		{"goto 42", "goto 42", "at line 42"},
+		{"goto top", "goto 0", "at the top"},
+		{"goto end", "goto -1", "at the end"},
+		{"goto 0", "", `invalid line "0"`},
+		{"save out.log", "save out.log", ""},
+		{"highlight WARN yellow", `highlight "WARN" yellow`, `highlighting "WARN" yellow`},
+		{"highlight WARN nope", "", `unknown color "nope"`},
+		{"set numbers off", "set numbers false", "numbers = false"},
+		{"set follow", "set follow <nil>", ""},
+		{"set numbers maybe", "", "numbers: expected on or off"},
+		{"set nope 1", "", `unknown option "nope"`},
+		{"set copy JSON", "set copy json", "copy = json"},
+		{"set copy html", "", "copy: expected one of text, raw, json"},
+		{"columns time level msg", "columns [time level msg]", "columns time, level, msg"},
+		{"columns time,msg", "columns [time msg]", "columns time, msg"},
+		{"columns", "columns []", "showing all columns"},
+		{"sort time", "sort time false", "sorting by time"},
+		{"sort took desc", "sort took true", "sorting by took descending"},
+		{"sort took up", "", `expected asc or desc, got "up"`},
+		{"sort", "sort  false", "sort cleared"},
+		{`query .level == "error"`, `filter .level == "error"`, `querying .level == "error"`},
+		{"query", "filter nil", "query cleared"},
+		{"query .a ==", "", "invalid query - unexpected end of query"},
+	} {
+		t.Run(test.line, func(t *testing.T) {
+			target := &recordTarget{}
+			message, err := executor.Execute(test.line, target)
+
+			actual := message
+			if err != nil {
+				actual = err.Error()
+			}
+			if !strings.HasPrefix(actual, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+
+			call := strings.Join(target.calls, ", ")
+			if call != test.call {
+				t.Errorf("expected call %q, got %q", test.call, call)
+			}
+		})
+	}
+}
+
+func Test_FilterMatch(t *testing.T) {
+	target := &recordTarget{}
+	NewExecutor(nil).Execute("filter -v debug", target)
+
+	if target.filter.Match("level=debug msg=x") {
+		t.Error("expected debug line to be filtered out")
+	}
+	if !target.filter.Match("level=info msg=x") {
+		t.Error("expected info line to be kept")
+	}
+	if target.filter.Match("level=info msg=x\n  level=debug in trace") {
+		t.Error("expected record with a debug line to be filtered out")
+	}
+
+	NewExecutor(nil).Execute("filter ^Caused", target)
+	if !target.filter.Match("ERROR x\nCaused by: y") {
+		t.Error("expected ^ to match at any line of a record")
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the options of `set`.
+const (
+	OptionNumbers  = "numbers"
+	OptionFollow   = "follow"
+	OptionWrap     = "wrap"
+	OptionColors   = "colors"
+	OptionCollapse = "collapse"
+	OptionTable    = "table"
+	OptionCopy     = "copy"
+)
+
+// Formats of the lines copied to the clipboard.
+const (
+	CopyRaw  = "raw"  // as read, with their escape codes
+	CopyText = "text" // without their escape codes
+	CopyJSON = "json" // as a JSON array of the records
+)
+
+type OptionKind uint
+
+const (
+	BoolOption OptionKind = iota
+	ChoiceOption
+)
+
+// Option - a setting that can be changed with `set`.
+type Option struct {
+	Name    string
+	Kind    OptionKind
+	Help    string
+	Choices []string // values of a choice option
+}
+
+// Options - every option of `set`.
+var Options = []Option{
+	{OptionNumbers, BoolOption, "show line numbers", nil},
+	{OptionFollow, BoolOption, "tail the live logs", nil},
+	{OptionWrap, BoolOption, "wrap long lines instead of scrolling sideways", nil},
+	{OptionColors, BoolOption, "show the colors of the logs", nil},
+	{OptionCollapse, BoolOption, "show repeated lines once with their count", nil},
+	{OptionTable, BoolOption, "show structured lines as a table of their fields", nil},
+	{OptionCopy, ChoiceOption, "format of the lines copied to the clipboard", []string{CopyText, CopyRaw, CopyJSON}},
+}
+
+// LookupOption - returns the option with the `name`.
+func LookupOption(name string) (Option, bool) {
+	for _, option := range Options {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+// Parse - parses the value of the option, `bool` for bool options (on/off,
+// true/false, yes/no) and `string` for choice options.
+func (o Option) Parse(value string) (any, error) {
+	switch o.Kind {
+	case BoolOption:
+		switch strings.ToLower(value) {
+		case "on", "true", "yes", "1":
+			return true, nil
+		case "off", "false", "no", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected on or off, got %q", value)
+	case ChoiceOption:
+		for _, choice := range o.Choices {
+			if strings.EqualFold(value, choice) {
+				return choice, nil
+			}
+		}
+		return nil, fmt.Errorf("expected one of %s, got %q", strings.Join(o.Choices, ", "), value)
+	}
+
+	return nil, fmt.Errorf("unknown option kind")
+}
+
+// ----------------------- PRIVATE
+
+func optionNames() string {
+	all := make([]string, len(Options))
+	for i, option := range Options {
+		all[i] = option.Name
+	}
+	return strings.Join(all, ", ")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/utils"
+)
+
+// Command - a parsed command line.
+type Command struct {
+	Name string
+	Args []string
+	Raw  string // the text after the name, as typed
+}
+
+// Spec - describes a command of the prompt.
+type Spec struct {
+	Name    string
+	Usage   string
+	Help    string
+	MinArgs int
+	MaxArgs int // -1 for no limit
+}
+
+// Specs - every command understood by the prompt.
+var Specs = []Spec{
+	{"filter", `filter [-v] [<regex> | @<saved filter>]`, "show only matching lines, no argument clears", 0, 2},
+	{"search", `search [<regex>]`, "highlight matches, jump with search-next/prev, no argument clears", 0, 1},
+	{"save", `save <path>`, "write the lines shown to a file", 1, 1},
+	{"goto", `goto <line> | top | end`, "move the cursor to a line", 1, 1},
+	{"highlight", `highlight "<regex>" <style>...`, "style matching text, e.g. yellow bold bg=red", 2, -1},
+	{"set", `set <option> [<value>]`, "change an option, no value shows it", 1, 2},
+	{"columns", `columns [<field>...]`, "choose and order the table columns, no argument shows all", 0, -1},
+	{"sort", `sort [<field> [asc | desc]]`, "sort the table rows by a field, no argument clears", 0, 2},
+	{"query", `query [<jq filter>]`, `filter or project JSON lines, e.g. .level == "error" or select(.user) | .msg`, 0, -1},
+}
+
+// Lookup - returns the spec of the command with the `name`.
+func Lookup(name string) (Spec, bool) {
+	for _, spec := range Specs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return Spec{}, false
+}
+
+// Parse - parses the `line` into a command and checks it against its spec.
+// Returns a nil command for a blank line.
+func Parse(line string) (*Command, error) {
+	args, err := utils.SplitArgs(line)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	name := strings.ToLower(args[0])
+	spec, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown command %q, expected one of %s", args[0], names())
+	}
+
+	if n := len(args) - 1; n < spec.MinArgs || (spec.MaxArgs >= 0 && n > spec.MaxArgs) {
+		return nil, fmt.Errorf("usage: %s", spec.Usage)
+	}
+
+	_, raw, _ := strings.Cut(strings.TrimSpace(line), args[0])
+	return &Command{Name: name, Args: args[1:], Raw: strings.TrimSpace(raw)}, nil
+}
+
+// ----------------------- PRIVATE
+
+func names() string {
+	all := make([]string, len(Specs))
+	for i, spec := range Specs {
+		all[i] = spec.Name
+	}
+	return strings.Join(all, ", ")
+}
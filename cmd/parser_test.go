@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ParseTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		line     string
+		expected string // name and args joined by "|", or the error
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"filter", "filter"},
+		{"filter -v error", "filter|-v|error"},
+		{`FILTER "a b"`, "filter|a b"},
+		{"goto 10", "goto|10"},
+		{`highlight "ERROR \d+" red bold`, `highlight|ERROR \d+|red|bold`},
+		{"set numbers off", "set|numbers|off"},
+		{"nope", `unknown command "nope"`},
+		{"goto", "usage: goto"},
+		{"save a b", "usage: save"},
+		{"highlight x", "usage: highlight"},
+		{`search "open`, "missing closing quote"},
+	} {
+		t.Run(test.line, func(t *testing.T) {
+			command, err := Parse(test.line)
+
+			var actual string
+			switch {
+			case err != nil:
+				actual = err.Error()
+			case command != nil:
+				actual = strings.Join(append([]string{command.Name}, command.Args...), "|")
+			}
+
+			if !strings.HasPrefix(actual, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_ParseRaw(t *testing.T) {
+	command, err := Parse(`  query   .level == "error"  and .took > 5 `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `.level == "error"  and .took > 5`; command.Raw != expected {
+		t.Errorf("expected %q, got %q", expected, command.Raw)
+	}
+}
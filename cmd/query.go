@@ -0,0 +1,669 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query - a jq style program run on the JSON records. It either filters the
+// records, giving a boolean or the record itself as with `select`, or
+// projects them into the value it gives.
+type Query struct {
+	source   string
+	root     node
+	projects bool // if `true` the value given replaces the record shown
+}
+
+// ParseQuery - parses the jq style `source`. Supported are paths (`.a.b`,
+// `.a[0]`, `.["a b"]`), literals, comparisons, `and`, `or`, pipes and the
+// functions select, not, length, has, contains, test, keys,
+// ascii_downcase, ascii_upcase, tostring and tonumber.
+func ParseQuery(source string) (*Query, error) {
+	tokens, err := lexQuery(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	root, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+
+	return &Query{source: source, root: root, projects: projects(root)}, nil
+}
+
+// Run - runs the query on the JSON `record`. Returns its result, and if it
+// is kept: records that are not JSON and results that are empty, false or
+// null are not.
+func (q *Query) Run(record string) (any, bool) {
+	var input any
+	if json.Unmarshal([]byte(record), &input) != nil {
+		return nil, false
+	}
+
+	value, ok := q.root.eval(input)
+	if !ok || value == nil || value == false {
+		return nil, false
+	}
+	return value, true
+}
+
+// Match - reports if the record, given as its lines joined by `\n`, is kept.
+// The first line holds the JSON.
+func (q *Query) Match(record string) bool {
+	line, _, _ := strings.Cut(record, "\n")
+	_, ok := q.Run(line)
+	return ok
+}
+
+// Project - returns the `line` as shown once projected by the query. Strings
+// are shown raw and other values as compact JSON. Returns `false` if the
+// query does not project the line.
+func (q *Query) Project(line string) (string, bool) {
+	if !q.projects {
+		return "", false
+	}
+
+	value, ok := q.Run(line)
+	if _, filters := value.(bool); !ok || filters {
+		return "", false
+	}
+	return queryText(value), true
+}
+
+func (q *Query) String() string {
+	return q.source
+}
+
+// ----------------------- PRIVATE
+
+// node - a part of a query, giving a value for its input or none.
+type node interface {
+	eval(input any) (any, bool)
+}
+
+type (
+	identity   struct{}
+	literal    struct{ value any }
+	pipeNode   struct{ left, right node }
+	indexNode  struct{ target, key node }
+	binaryNode struct {
+		op          string
+		left, right node
+	}
+	callNode struct {
+		name string
+		args []node
+	}
+)
+
+func (identity) eval(input any) (any, bool) { return input, true }
+func (n literal) eval(any) (any, bool)      { return n.value, true }
+
+func (n pipeNode) eval(input any) (any, bool) {
+	value, ok := n.left.eval(input)
+	if !ok {
+		return nil, false
+	}
+	return n.right.eval(value)
+}
+
+func (n indexNode) eval(input any) (any, bool) {
+	target, ok := n.target.eval(input)
+	if !ok {
+		return nil, false
+	}
+	key, ok := n.key.eval(input)
+	if !ok {
+		return nil, false
+	}
+
+	switch target := target.(type) {
+	case map[string]any:
+		name, _ := key.(string)
+		return target[name], true
+	case []any:
+		i, isNumber := key.(float64)
+		if !isNumber {
+			return nil, true
+		}
+		index := int(i)
+		if index < 0 {
+			index += len(target)
+		}
+		if index < 0 || index >= len(target) {
+			return nil, true
+		}
+		return target[index], true
+	}
+	return nil, true
+}
+
+func (n binaryNode) eval(input any) (any, bool) {
+	left, ok := n.left.eval(input)
+	if !ok {
+		return nil, false
+	}
+
+	// `and` and `or` do not evaluate their right side when not needed
+	switch n.op {
+	case "and":
+		if !truthy(left) {
+			return false, true
+		}
+	case "or":
+		if truthy(left) {
+			return true, true
+		}
+	}
+
+	right, ok := n.right.eval(input)
+	if !ok {
+		return nil, false
+	}
+
+	switch n.op {
+	case "and", "or":
+		return truthy(right), true
+	case "==":
+		return compareQuery(left, right) == 0, true
+	case "!=":
+		return compareQuery(left, right) != 0, true
+	case "<":
+		return compareQuery(left, right) < 0, true
+	case "<=":
+		return compareQuery(left, right) <= 0, true
+	case ">":
+		return compareQuery(left, right) > 0, true
+	case ">=":
+		return compareQuery(left, right) >= 0, true
+	}
+	return nil, false
+}
+
+func (n callNode) eval(input any) (any, bool) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		value, ok := arg.eval(input)
+		if !ok {
+			return nil, false
+		}
+		args[i] = value
+	}
+
+	switch n.name {
+	case "select":
+		if truthy(args[0]) {
+			return input, true
+		}
+		return nil, false
+	case "not":
+		return !truthy(input), true
+	case "length":
+		switch input := input.(type) {
+		case string:
+			return float64(len([]rune(input))), true
+		case []any:
+			return float64(len(input)), true
+		case map[string]any:
+			return float64(len(input)), true
+		case float64:
+			return max(input, -input), true
+		}
+		return float64(0), true
+	case "keys":
+		object, ok := input.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = key
+		}
+		return values, true
+	case "has":
+		object, isObject := input.(map[string]any)
+		key, isKey := args[0].(string)
+		if !isObject || !isKey {
+			return false, true
+		}
+		_, found := object[key]
+		return found, true
+	case "contains":
+		return contains(input, args[0]), true
+	case "test":
+		text, isText := input.(string)
+		pattern, isPattern := args[0].(string)
+		if !isText || !isPattern {
+			return false, true
+		}
+		re, err := regexp.Compile(pattern)
+		return err == nil && re.MatchString(text), true
+	case "ascii_downcase", "ascii_upcase":
+		text, ok := input.(string)
+		if !ok {
+			return nil, false
+		}
+		if n.name == "ascii_downcase" {
+			return strings.ToLower(text), true
+		}
+		return strings.ToUpper(text), true
+	case "tostring":
+		return queryText(input), true
+	case "tonumber":
+		switch input := input.(type) {
+		case float64:
+			return input, true
+		case string:
+			number, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+			return number, err == nil
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// functions - the arguments taken by each function.
+var functions = map[string]int{
+	"select": 1, "not": 0, "length": 0, "keys": 0, "has": 1, "contains": 1,
+	"test": 1, "ascii_downcase": 0, "ascii_upcase": 0, "tostring": 0, "tonumber": 0,
+}
+
+// projects - reports if the query gives other values than its input or
+// booleans, that is if it does not end with `select` or `.`.
+func projects(root node) bool {
+	for {
+		pipe, ok := root.(pipeNode)
+		if !ok {
+			break
+		}
+		root = pipe.right
+	}
+
+	switch root := root.(type) {
+	case identity:
+		return false
+	case callNode:
+		return root.name != "select"
+	}
+	return true
+}
+
+// truthy - reports if the value counts as true, all but false and null do.
+func truthy(value any) bool {
+	return value != nil && value != false
+}
+
+// compareQuery - orders values the way jq does: null, false, true, numbers,
+// strings, arrays then objects.
+func compareQuery(a, b any) int {
+	if rank(a) != rank(b) {
+		return rank(a) - rank(b)
+	}
+
+	switch a := a.(type) {
+	case float64:
+		switch y := b.(float64); {
+		case a < y:
+			return -1
+		case a > y:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return strings.Compare(queryText(a), queryText(b))
+}
+
+func rank(value any) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []any:
+		return 5
+	}
+	return 6
+}
+
+// contains - reports if `b` is within `a`: a substring, elements within the
+// elements of an array, or values within those of the same keys.
+func contains(a, b any) bool {
+	switch a := a.(type) {
+	case string:
+		text, ok := b.(string)
+		return ok && strings.Contains(a, text)
+	case []any:
+		elements, ok := b.([]any)
+		if !ok {
+			return false
+		}
+		for _, element := range elements {
+			found := false
+			for _, each := range a {
+				if contains(each, element) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		object, ok := b.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range object {
+			if have, found := a[key]; !found || !contains(have, value) {
+				return false
+			}
+		}
+		return true
+	}
+	return compareQuery(a, b) == 0
+}
+
+// queryText - returns strings raw and other values as compact JSON.
+func queryText(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+type queryToken struct {
+	kind string // "op", "ident", "field", "string" or "number"
+	text string
+}
+
+// lexQuery - splits the `source` into tokens.
+func lexQuery(source string) ([]queryToken, error) {
+	var tokens []queryToken
+
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i += 1
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				if runes[end] == '\\' {
+					end += 1
+				}
+				end += 1
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("missing closing quote")
+			}
+			text, err := strconv.Unquote(string(runes[i : end+1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", string(runes[i:end+1]))
+			}
+			tokens = append(tokens, queryToken{"string", text})
+			i = end + 1
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end += 1
+			}
+			tokens = append(tokens, queryToken{"number", string(runes[i:end])})
+			i = end
+		case r == '.' && i+1 < len(runes) && isIdentRune(runes[i+1], true):
+			end := i + 1
+			for end < len(runes) && isIdentRune(runes[end], end == i+1) {
+				end += 1
+			}
+			tokens = append(tokens, queryToken{"field", string(runes[i+1 : end])})
+			i = end
+		case isIdentRune(r, true):
+			end := i
+			for end < len(runes) && isIdentRune(runes[end], end == i) {
+				end += 1
+			}
+			tokens = append(tokens, queryToken{"ident", string(runes[i:end])})
+			i = end
+		default:
+			op := string(r)
+			if i+1 < len(runes) && twoCharOps[string(runes[i:i+2])] {
+				op = string(runes[i : i+2])
+			} else if !strings.ContainsRune(".|()[];-<>", r) {
+				return nil, fmt.Errorf("unexpected %q", op)
+			}
+			tokens = append(tokens, queryToken{"op", op})
+			i += len([]rune(op))
+		}
+	}
+
+	return tokens, nil
+}
+
+var twoCharOps = map[string]bool{"==": true, "!=": true, "<=": true, ">=": true}
+
+func isIdentRune(r rune, first bool) bool {
+	return r == '_' || unicode.IsLetter(r) || (!first && unicode.IsDigit(r))
+}
+
+// queryParser - parses the tokens of a query by recursive descent, from the
+// pipes binding the loosest to the paths binding the tightest.
+type queryParser struct {
+	tokens []queryToken
+	at     int
+}
+
+func (p *queryParser) done() bool {
+	return p.at >= len(p.tokens)
+}
+
+func (p *queryParser) peek() queryToken {
+	if p.done() {
+		return queryToken{}
+	}
+	return p.tokens[p.at]
+}
+
+// accept - consumes the next token if it is the operator or keyword `text`.
+func (p *queryParser) accept(text string) bool {
+	if token := p.peek(); (token.kind == "op" || token.kind == "ident") && token.text == text {
+		p.at += 1
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expect(text string) error {
+	if !p.accept(text) {
+		if p.done() {
+			return fmt.Errorf("expected %q at the end", text)
+		}
+		return fmt.Errorf("expected %q, got %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *queryParser) pipe() (node, error) {
+	left, err := p.or()
+	for err == nil && p.accept("|") {
+		var right node
+		right, err = p.or()
+		left = pipeNode{left, right}
+	}
+	return left, err
+}
+
+func (p *queryParser) or() (node, error) {
+	left, err := p.and()
+	for err == nil && p.accept("or") {
+		var right node
+		right, err = p.and()
+		left = binaryNode{"or", left, right}
+	}
+	return left, err
+}
+
+func (p *queryParser) and() (node, error) {
+	left, err := p.comparison()
+	for err == nil && p.accept("and") {
+		var right node
+		right, err = p.comparison()
+		left = binaryNode{"and", left, right}
+	}
+	return left, err
+}
+
+func (p *queryParser) comparison() (node, error) {
+	left, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.postfix()
+			return binaryNode{op, left, right}, err
+		}
+	}
+	return left, nil
+}
+
+// postfix - parses a term followed by its paths, like `.a.b[0]`.
+func (p *queryParser) postfix() (node, error) {
+	term, err := p.term()
+	for err == nil {
+		switch token := p.peek(); {
+		case token.kind == "field":
+			p.at += 1
+			term = indexNode{term, literal{token.text}}
+		case token.kind == "op" && token.text == "[":
+			p.at += 1
+			var key node
+			if key, err = p.pipe(); err == nil {
+				err = p.expect("]")
+			}
+			term = indexNode{term, key}
+		case token.kind == "op" && token.text == "." && p.at+1 < len(p.tokens) && p.tokens[p.at+1].text == "[":
+			p.at += 1
+		default:
+			return term, nil
+		}
+	}
+	return nil, err
+}
+
+func (p *queryParser) term() (node, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	token := p.tokens[p.at]
+	p.at += 1
+
+	switch token.kind {
+	case "field":
+		return indexNode{identity{}, literal{token.text}}, nil
+	case "string":
+		return literal{token.text}, nil
+	case "number":
+		number, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token.text)
+		}
+		return literal{number}, nil
+	case "ident":
+		return p.call(token.text)
+	}
+
+	switch token.text {
+	case ".":
+		return identity{}, nil
+	case "(":
+		inner, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case "-":
+		if next := p.peek(); next.kind == "number" {
+			p.at += 1
+			number, err := strconv.ParseFloat("-"+next.text, 64)
+			return literal{number}, err
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q", token.text)
+}
+
+// call - parses the keyword or function call `name`, its arguments separated
+// by `;`.
+func (p *queryParser) call(name string) (node, error) {
+	switch name {
+	case "true":
+		return literal{true}, nil
+	case "false":
+		return literal{false}, nil
+	case "null":
+		return literal{nil}, nil
+	}
+
+	count, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	var args []node
+	if count > 0 {
+		if err := p.expect("("); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for len(args) < count {
+			if len(args) > 0 {
+				if err := p.expect(";"); err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+			}
+			arg, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return callNode{name, args}, nil
+}
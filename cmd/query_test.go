@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func Test_QueryTableDriven(t *testing.T) {
+	record := `{"level":"error","msg":"upstream failed","http":{"status":502,"path":"/api"},` +
+		`"user":{"id":7,"name":"Ada"},"tags":["db","retry"],"took":1.5,"cached":false}`
+
+	for _, test := range []struct {
+		query   string
+		kept    bool
+		shown   string // projected line, empty if shown as is
+		invalid bool
+	}{
+		{query: `.level == "error"`, kept: true},
+		{query: `.level == "info"`, kept: false},
+		{query: `.level == "error" and .http.status >= 500`, kept: true},
+		{query: `.level == "error" and .http.status < 500`, kept: false},
+		{query: `.level == "info" or .took > 1`, kept: true},
+		{query: `.missing`, kept: false},
+		{query: `.cached`, kept: false},
+		{query: `.cached | not`, kept: true},
+		{query: `select(.user.id) | .msg`, kept: true, shown: "upstream failed"},
+		{query: `select(.user.missing) | .msg`, kept: false},
+		{query: `.http`, kept: true, shown: `{"path":"/api","status":502}`},
+		{query: `.tags[1]`, kept: true, shown: "retry"},
+		{query: `.tags[-1] == "retry"`, kept: true},
+		{query: `.["level"]`, kept: true, shown: "error"},
+		{query: `select(.msg | test("^up"))`, kept: true},
+		{query: `.msg | contains("fail")`, kept: true},
+		{query: `.tags | length`, kept: true, shown: "2"},
+		{query: `has("user") and (.user | has("id"))`, kept: true},
+		{query: `.user.name | ascii_upcase`, kept: true, shown: "ADA"},
+		{query: `.took == -1.5`, kept: false},
+		{query: `select(.level == "error") | .`, kept: true},
+		{query: `.level ==`, invalid: true},
+		{query: `.level = "x"`, invalid: true},
+		{query: `nope(.a)`, invalid: true},
+		{query: `select(.a`, invalid: true},
+		{query: `"open`, invalid: true},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := ParseQuery(test.query)
+			if test.invalid {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if kept := query.Match(record + "\n\tat frame"); kept != test.kept {
+				t.Errorf("expected kept %v, got %v", test.kept, kept)
+			}
+
+			shown, projected := query.Project(record)
+			if projected != (len(test.shown) > 0) || shown != test.shown {
+				t.Errorf("expected shown %q, got %q (projected %v)", test.shown, shown, projected)
+			}
+		})
+	}
+}
+
+func Test_QuerySkipsPlainLines(t *testing.T) {
+	query, err := ParseQuery(`.msg`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if query.Match("ERROR not json") {
+		t.Error("expected plain lines to be filtered out")
+	}
+}
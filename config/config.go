@@ -0,0 +1,215 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/keymap"
+)
+
+const (
+	appDirName        = "logctrl"
+	userFileName      = "config.json"
+	ProjectFileName   = ".logctrl"
+	defaultPromptSize = 16
+	defaultPanelSize  = 8
+	defaultSummary    = 12
+	defaultDetail     = 14
+	defaultMaxLine    = 64 * 1024
+	defaultMaxFPS     = 30
+	unknownFieldError = "json: unknown field "
+)
+
+type Config struct {
+	Keys      map[string][]string `json:"keys"`      // action -> key sequences
+	Theme     Theme               `json:"theme"`     // colors of the UI
+	Highlight []string            `json:"highlight"` // `"<pattern>" <style>...`
+	Filters   map[string]string   `json:"filters"`   // name -> regex
+	Parsers   []Parser            `json:"parsers"`   // structured log formats
+	Session   Session             `json:"session"`   // session storage
+	Layout    Layout              `json:"layout"`    // sizes of the panes
+	View      View                `json:"view"`      // how the logs are shown
+}
+
+// Theme - colors as names (e.g. `yellow`), ANSI 256 numbers or hex values.
+type Theme struct {
+	Border     string `json:"border"`
+	ToolbarFg  string `json:"toolbar_fg"`
+	ToolbarBg  string `json:"toolbar_bg"`
+	ToolbarKey string `json:"toolbar_key"`
+	Gutter     string `json:"gutter"`
+	Bookmark   string `json:"bookmark"`
+	Error      string `json:"error"`
+}
+
+// Parser - a structured log format. `Format` is one of `json`, `logfmt` or
+// `regex`, for which `Pattern` holds the regex with named groups as fields.
+type Parser struct {
+	Name    string `json:"name"`
+	Format  string `json:"format"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Session - where session files are stored.
+type Session struct {
+	Dir          string `json:"dir"`           // directory of new session files, temp dir if empty
+	KeepProgress bool   `json:"keep_progress"` // keep the states overwritten by `\r` in the session file
+}
+
+// Layout - heights of the panes in lines.
+type Layout struct {
+	PromptHeight    int `json:"prompt_height"`
+	BookmarksHeight int `json:"bookmarks_height"`
+	SummaryHeight   int `json:"summary_height"`
+	DetailHeight    int `json:"detail_height"`
+}
+
+// View - how the logs are shown.
+type View struct {
+	MaxLineLength int      `json:"max_line_length"` // bytes shown of a line, 0 for no limit
+	Columns       []string `json:"columns"`         // fields shown in the table mode, all if empty
+	MaxFPS        int      `json:"max_fps"`         // renders of new logs per second, 0 for no limit
+}
+
+// Default - returns the configuration used when no config file is present.
+func Default() Config {
+	return Config{
+		Keys: map[string][]string{},
+		Theme: Theme{
+			Border:     "63",
+			ToolbarFg:  "black",
+			ToolbarBg:  "43",
+			ToolbarKey: "magenta",
+			Gutter:     "grey",
+			Bookmark:   "214",
+			Error:      "red",
+		},
+		Filters: map[string]string{},
+		Layout: Layout{
+			PromptHeight:    defaultPromptSize,
+			BookmarksHeight: defaultPanelSize,
+			SummaryHeight:   defaultSummary,
+			DetailHeight:    defaultDetail,
+		},
+		View: View{
+			MaxLineLength: defaultMaxLine,
+			MaxFPS:        defaultMaxFPS,
+		},
+	}
+}
+
+// UserPath - returns the location of the user's config file, following the
+// XDG base directory spec (`$XDG_CONFIG_HOME/logctrl/config.json`).
+func UserPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appDirName, userFileName)
+}
+
+// Load - reads the user's config file and then the project's `.logctrl` file
+// of the current directory on top of the defaults. Fields set by a later file
+// replace the earlier ones, except maps (keys and filters) which are merged
+// per entry. Actions not bound in the files keep their default keys. Missing
+// files are skipped. All the problems found are reported together in the
+// returned error.
+func Load() (Config, error) {
+	cfg := Default()
+
+	var errs Errors
+	for _, path := range []string{UserPath(), ProjectFileName} {
+		if len(path) == 0 {
+			continue
+		}
+
+		raw, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		// validate the file on its own so that problems are reported
+		// against the file they come from
+		var layer Config
+		if err := decode(raw, &layer); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if layerErrs := layer.validate(); len(layerErrs) > 0 {
+			for _, err := range layerErrs {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
+			continue
+		}
+
+		decode(raw, &cfg)
+	}
+
+	// keys of different files may conflict once merged
+	if len(errs) == 0 {
+		if _, err := keymap.New(cfg.Keys); err != nil {
+			errs = append(errs, fmt.Errorf("keys: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// Errors - all problems found in the config files.
+type Errors []error
+
+func (e Errors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = "  " + err.Error()
+	}
+	return "invalid configuration\n" + strings.Join(lines, "\n")
+}
+
+// ----------------------- PRIVATE
+
+// decode - decodes the config file contents over the `cfg`.
+func decode(raw []byte, cfg *Config) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return describeJSONError(raw, err, decoder.InputOffset())
+	}
+	return nil
+}
+
+// describeJSONError - adds the line number to JSON errors. The offset of the
+// error is used if known, `offset` (where the decoder stopped) otherwise.
+func describeJSONError(raw []byte, err error, offset int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	case strings.HasPrefix(err.Error(), unknownFieldError):
+		// the decoder doesn't report where, so point at the first use
+		field := strings.TrimPrefix(err.Error(), unknownFieldError)
+		if i := bytes.Index(raw, []byte(field)); i >= 0 {
+			offset = int64(i)
+		}
+	}
+
+	line := bytes.Count(raw[:min(offset, int64(len(raw)))], []byte("\n")) + 1
+	return fmt.Errorf("line %d: %w", line, err)
+}
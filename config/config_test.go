@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SpandanBG/logctrl/keymap"
+)
+
+// withFiles - points the config lookup to a temp dir holding the user and
+// project config files with the given contents. Empty contents skip the file.
+func withFiles(t *testing.T, user, project string) {
+	home := t.TempDir()
+	cwd := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	if len(user) > 0 {
+		dir := filepath.Join(home, appDirName)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, userFileName), []byte(user), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(project) > 0 {
+		if err := os.WriteFile(filepath.Join(cwd, ProjectFileName), []byte(project), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(old) })
+}
+
+func Test_LoadDefaults(t *testing.T) {
+	withFiles(t, "", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Layout.PromptHeight != defaultPromptSize {
+		t.Errorf("expected default prompt height, got %d", cfg.Layout.PromptHeight)
+	}
+	if keys := cfg.Keymap().Keys(keymap.Quit); len(keys) == 0 || keys[0] != "q" {
+		t.Errorf("expected q to quit by default, got %v", keys)
+	}
+}
+
+func Test_LoadProjectOverridesUser(t *testing.T) {
+	withFiles(t,
+		`{"theme": {"border": "red"}, "filters": {"errors": "ERROR"}, "layout": {"prompt_height": 10}}`,
+		`{"theme": {"border": "blue"}, "filters": {"slow": "took \\d+s"}, "keys": {"quit": ["x"]}}`,
+	)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Theme.Border != "blue" {
+		t.Errorf("expected project border, got %q", cfg.Theme.Border)
+	}
+	if cfg.Theme.Gutter != "grey" {
+		t.Errorf("expected default gutter to be kept, got %q", cfg.Theme.Gutter)
+	}
+	if cfg.Layout.PromptHeight != 10 {
+		t.Errorf("expected user prompt height, got %d", cfg.Layout.PromptHeight)
+	}
+	if len(cfg.Filters) != 2 {
+		t.Errorf("expected filters to be merged, got %v", cfg.Filters)
+	}
+
+	_, action, _ := cfg.Keymap().Resolve(keymap.LogView, "x")
+	_, old, _ := cfg.Keymap().Resolve(keymap.LogView, "q")
+	if action != keymap.Quit || len(old) != 0 {
+		t.Errorf("expected quit to be rebound to x, got %v", cfg.Keymap().Keys(keymap.Quit))
+	}
+}
+
+func Test_LoadValidationErrors(t *testing.T) {
+	withFiles(t,
+		`{"keys": {"quitt": ["x"]}, "theme": {"border": "blu"}}`,
+		`{
+  "highlight": ["\"(\" red"],
+  "filters": {"bad": "["},
+  "parsers": [{"name": "p", "format": "regex", "pattern": "\\w+"}],
+  "unknown": true
+}`,
+	)
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, expected := range []string{
+		`config.json: keys: unknown action "quitt"`,
+		`config.json: theme.border: unknown color "blu"`,
+		`.logctrl: line 5: json: unknown field "unknown"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in:\n%v", expected, err)
+		}
+	}
+}
+
+func Test_LoadFieldErrors(t *testing.T) {
+	withFiles(t, "", `{
+  "highlight": ["\"(\" red"],
+  "filters": {"bad": "["},
+  "parsers": [{"name": "p", "format": "regex", "pattern": "\\w+"}, {"name": "q", "format": "xml"}],
+  "keys": {"bookmark": ["q"]},
+  "view": {"max_line_length": -1, "max_fps": -5}
+}`)
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, expected := range []string{
+		"highlight[0]: invalid pattern",
+		"filters.bad:",
+		"parsers[0]: p: pattern needs named groups",
+		`parsers[1]: q: unknown format "xml"`,
+		"view: max_line_length can not be negative",
+		"view: max_fps can not be negative",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in:\n%v", expected, err)
+		}
+	}
+}
+
+func Test_LoadKeyConflicts(t *testing.T) {
+	withFiles(t, "", `{"keys": {"bookmark": ["q"]}}`)
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), `"q" is bound to both "quit" and "bookmark"`) {
+		t.Errorf("expected key conflict error, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/SpandanBG/logctrl/highlight"
+	"github.com/SpandanBG/logctrl/keymap"
+	"github.com/SpandanBG/logctrl/structured"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+	FormatRegex  = "regex"
+)
+
+// Rules - returns the compiled highlight rules. Invalid rules are skipped,
+// they are reported by `Load`.
+func (c Config) Rules() highlight.Rules {
+	var rules highlight.Rules
+	for _, each := range c.Highlight {
+		if rule, err := highlight.ParseRule(each); err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Structured - returns the parsers of the structured log formats, those of
+// the config first and then JSON and logfmt. Invalid parsers are skipped, they
+// are reported by `Load`.
+func (c Config) Structured() structured.Parsers {
+	var parsers structured.Parsers
+	for _, each := range c.Parsers {
+		if parser, err := structured.NewParser(each.Format, each.Pattern); err == nil {
+			parsers = append(parsers, parser)
+		}
+	}
+	return append(parsers, structured.Default()...)
+}
+
+// Keymap - returns the keymap with the keys of the config. Invalid bindings
+// are reported by `Load`.
+func (c Config) Keymap() keymap.Keymap {
+	k, _ := keymap.New(c.Keys)
+	return k
+}
+
+// ----------------------- PRIVATE
+
+// validate - checks the values set in the config, in a stable order.
+func (c Config) validate() []error {
+	var errs []error
+
+	if _, err := keymap.New(c.Keys); err != nil {
+		errs = append(errs, fmt.Errorf("keys: %w", err))
+	}
+
+	for _, color := range []struct{ name, value string }{
+		{"border", c.Theme.Border},
+		{"toolbar_fg", c.Theme.ToolbarFg},
+		{"toolbar_bg", c.Theme.ToolbarBg},
+		{"toolbar_key", c.Theme.ToolbarKey},
+		{"gutter", c.Theme.Gutter},
+		{"bookmark", c.Theme.Bookmark},
+		{"error", c.Theme.Error},
+	} {
+		if len(color.value) == 0 {
+			continue
+		}
+		if _, err := highlight.ParseColor(color.value); err != nil {
+			errs = append(errs, fmt.Errorf("theme.%s: %w", color.name, err))
+		}
+	}
+
+	for i, rule := range c.Highlight {
+		if _, err := highlight.ParseRule(rule); err != nil {
+			errs = append(errs, fmt.Errorf("highlight[%d]: %w", i, err))
+		}
+	}
+
+	for _, name := range sortedKeys(c.Filters) {
+		if _, err := regexp.Compile(c.Filters[name]); err != nil {
+			errs = append(errs, fmt.Errorf("filters.%s: %w", name, err))
+		}
+	}
+
+	for i, parser := range c.Parsers {
+		if err := parser.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("parsers[%d]: %w", i, err))
+		}
+	}
+
+	layout := c.Layout
+	if layout.PromptHeight < 0 || layout.BookmarksHeight < 0 || layout.SummaryHeight < 0 || layout.DetailHeight < 0 {
+		errs = append(errs, fmt.Errorf("layout: heights can not be negative"))
+	}
+
+	if c.View.MaxLineLength < 0 {
+		errs = append(errs, fmt.Errorf("view: max_line_length can not be negative"))
+	}
+
+	if c.View.MaxFPS < 0 {
+		errs = append(errs, fmt.Errorf("view: max_fps can not be negative"))
+	}
+
+	return errs
+}
+
+func (p Parser) validate() error {
+	if len(p.Name) == 0 {
+		return fmt.Errorf("missing name")
+	}
+
+	switch p.Format {
+	case FormatJSON, FormatLogfmt:
+		return nil
+	case FormatRegex:
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		for _, name := range re.SubexpNames() {
+			if len(name) > 0 {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: pattern needs named groups, e.g. (?P<level>\\w+)", p.Name)
+	default:
+		return fmt.Errorf(
+			"%s: unknown format %q, expected %s, %s or %s",
+			p.Name, p.Format, FormatJSON, FormatLogfmt, FormatRegex,
+		)
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,250 @@
+package headless
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/cmd"
+	"github.com/SpandanBG/logctrl/config"
+	"github.com/SpandanBG/logctrl/highlight"
+	"github.com/SpandanBG/logctrl/reader"
+	"github.com/SpandanBG/logctrl/structured"
+	"github.com/SpandanBG/logctrl/utils"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	repeatMarker = "  ×%d" // times the record above was read in a row
+	numberFormat = "%6d\t"
+)
+
+var (
+	searchStyle = lipgloss.NewStyle().
+			Reverse(true)
+	markerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8"))
+)
+
+// Printer - writes the log lines read to an output as the log view shows
+// them: records filtered or projected by a query, highlighted and collapsed
+// when repeated. The prompt commands change how, those moving a view or
+// saving lines are not available.
+type Printer interface {
+	Execute(string) error
+	Print(io.Reader) error
+}
+
+type printer struct {
+	out      io.Writer
+	executor cmd.Executor
+	rules    highlight.Rules
+	parsers  structured.Parsers
+	terminal bool
+
+	// state set by the commands
+	filter      cmd.Filter     // if set only the matching records are written
+	search      *regexp.Regexp // pattern highlighted
+	fields      []string       // fields written of the structured lines, all if empty
+	numbers     bool           // if `true` line numbers are written
+	colors      bool           // if `true` the escape codes of the logs are kept
+	collapse    bool           // if `true` repeated records are written once with their count
+	unsupported string         // command not available, set while executing
+
+	// state of the records written
+	line    int  // lines read
+	kept    bool // if `true` a record of the current run was written
+	repeats int  // records of the current run matching the filter
+}
+
+// NewPrinter - creates a printer writing to `out` with the highlight rules,
+// parsers and saved filters of the `cfg`. Lines are written whole. The escape
+// codes of the logs are kept and control bytes shown escaped if `terminal`.
+func NewPrinter(out io.Writer, cfg config.Config, terminal bool) Printer {
+	return &printer{
+		out:      out,
+		executor: cmd.NewExecutor(cfg.Filters),
+		rules:    cfg.Rules(),
+		parsers:  cfg.Structured(),
+		terminal: terminal,
+		colors:   terminal,
+		collapse: true,
+	}
+}
+
+// Execute - runs the prompt command `line`, to apply to the lines printed
+// next.
+func (p *printer) Execute(line string) error {
+	p.unsupported = ""
+	if _, err := p.executor.Execute(line, p); err != nil {
+		return err
+	}
+	if len(p.unsupported) > 0 {
+		return fmt.Errorf("%s is not available without the terminal UI", p.unsupported)
+	}
+	return nil
+}
+
+// Print - writes the lines read from `in` until its end. A record is written
+// once the next one starts, or `in` ends, as its lines are filtered together.
+func (p *printer) Print(in io.Reader) error {
+	var (
+		records reader.Records
+		record  []string
+		repeats bool
+	)
+
+	err := reader.ReadLines(in, func(line string) {
+		starts, repeat := records.Add(line)
+		if starts && len(record) > 0 {
+			p.write(record, repeats)
+			record = record[:0]
+		}
+		if starts {
+			repeats = repeat
+		}
+		record = append(record, line)
+	})
+
+	if len(record) > 0 {
+		p.write(record, repeats)
+	}
+	p.endRun()
+	return err
+}
+
+func (p *printer) Filter(filter cmd.Filter) {
+	p.filter = filter
+}
+
+func (p *printer) Search(pattern *regexp.Regexp) {
+	p.search = pattern
+}
+
+func (p *printer) Goto(int) {
+	p.unsupported = "goto"
+}
+
+func (p *printer) Save(string) {
+	p.unsupported = "save"
+}
+
+func (p *printer) Highlight(rule highlight.Rule) {
+	p.rules = append(p.rules, rule)
+}
+
+func (p *printer) Set(name string, value any) {
+	if value == nil {
+		return
+	}
+
+	switch name {
+	case cmd.OptionNumbers:
+		p.numbers = value.(bool)
+	case cmd.OptionColors:
+		p.colors = value.(bool)
+	case cmd.OptionCollapse:
+		p.collapse = value.(bool)
+	default:
+		p.unsupported = "set " + name
+	}
+}
+
+func (p *printer) Columns(fields []string) {
+	p.fields = fields
+}
+
+func (p *printer) Sort(string, bool) {
+	p.unsupported = "sort"
+}
+
+// ----------------------- PRIVATE
+
+// write - writes the `lines` of a record, unless filtered out or collapsed
+// with the records before it when it `repeats` them.
+func (p *printer) write(lines []string, repeats bool) {
+	first := p.line + 1
+	p.line += len(lines)
+
+	if !repeats {
+		p.endRun()
+	}
+
+	plain := make([]string, len(lines))
+	for i, line := range lines {
+		plain[i] = utils.StripANSI(utils.ApplyCarriageReturns(line))
+	}
+	if p.filter != nil && !p.filter.Match(strings.Join(plain, "\n")) {
+		return
+	}
+
+	p.repeats += 1
+	if p.collapse && p.kept {
+		return
+	}
+	p.kept = true
+
+	rules := p.rules.WithSearch(p.search, searchStyle)
+	var b strings.Builder
+	for i, line := range lines {
+		if p.numbers {
+			b.WriteString(fmt.Sprintf(numberFormat, first+i))
+		}
+		b.WriteString(p.render(rules, line, plain[i]))
+		b.WriteString("\n")
+	}
+	io.WriteString(p.out, b.String())
+}
+
+// endRun - writes the count of the records collapsed in the current run, if
+// any, and starts a new run.
+func (p *printer) endRun() {
+	if p.collapse && p.repeats > 1 {
+		io.WriteString(p.out, markerStyle.Render(fmt.Sprintf(repeatMarker, p.repeats))+"\n")
+	}
+	p.kept, p.repeats = false, 0
+}
+
+// render - returns the `line` as shown, `plain` being its plain text: the
+// output of a projecting query, the fields chosen of a structured line, or
+// the line itself, highlighted.
+func (p *printer) render(rules highlight.Rules, line, plain string) string {
+	if projector, ok := p.filter.(cmd.Projector); ok {
+		if text, ok := projector.Project(plain); ok {
+			line = text
+		}
+	} else if len(p.fields) > 0 {
+		if fields, ok := p.parsers.Parse(plain); ok {
+			line = p.pick(fields)
+		}
+	}
+
+	line = utils.ApplyCarriageReturns(line)
+	if p.terminal {
+		line = utils.Sanitize(line)
+	}
+	if !p.colors {
+		line = utils.StripANSI(line)
+	}
+
+	return rules.Apply(line)
+}
+
+// pick - returns the chosen `fields` found, as `key=value` pairs in the order
+// chosen. Values are quoted if required.
+func (p *printer) pick(fields structured.Fields) string {
+	var pairs []string
+	for _, key := range p.fields {
+		value, ok := fields.Get(key)
+		if !ok {
+			continue
+		}
+		if len(value) == 0 || strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,132 @@
+package headless
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SpandanBG/logctrl/config"
+)
+
+const logs = `start
+retry 1 in 200ms
+retry 2 in 400ms
+ERROR failed
+	at a.b(App.java:1)
+ERROR failed
+	at a.b(App.java:1)
+{"level":"error","msg":"db down","user":"x"}
+{"level":"info","msg":"ok"}
+done
+`
+
+func Test_PrinterTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		commands []string
+		expected string
+	}{
+		{
+			name:     "repeats collapsed",
+			expected: "start\nretry 1 in 200ms\n  ×2\nERROR failed\n\tat a.b(App.java:1)\n  ×2\n" + `{"level":"error","msg":"db down","user":"x"}` + "\n" + `{"level":"info","msg":"ok"}` + "\ndone\n",
+		},
+		{
+			name:     "records filtered",
+			commands: []string{"filter App.java", "set collapse off"},
+			expected: "ERROR failed\n\tat a.b(App.java:1)\nERROR failed\n\tat a.b(App.java:1)\n",
+		},
+		{
+			name:     "filter inverted with numbers",
+			commands: []string{"filter -v retry|ERROR|level", "set numbers on"},
+			expected: "     1\tstart\n    10\tdone\n",
+		},
+		{
+			name:     "run partly filtered out",
+			commands: []string{`filter "retry 2|done"`},
+			expected: "retry 2 in 400ms\ndone\n",
+		},
+		{
+			name:     "query projecting",
+			commands: []string{`query select(.level == "error") | .msg`},
+			expected: "db down\n",
+		},
+		{
+			name:     "columns of structured lines",
+			commands: []string{"filter level", "columns msg,level"},
+			expected: `msg="db down" level=error` + "\nmsg=ok level=info\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			p := NewPrinter(&out, config.Default(), false)
+			for _, command := range test.commands {
+				if err := p.Execute(command); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := p.Print(strings.NewReader(logs)); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+func Test_PrinterUnsupportedCommands(t *testing.T) {
+	p := NewPrinter(&strings.Builder{}, config.Default(), false)
+
+	for _, command := range []string{"goto 3", "save out.log", "sort level", "set wrap on", "bogus"} {
+		if err := p.Execute(command); err == nil {
+			t.Errorf("expected an error for %q", command)
+		}
+	}
+	for _, command := range []string{"set colors off", "highlight error red", "search x", "filter"} {
+		if err := p.Execute(command); err != nil {
+			t.Errorf("unexpected error for %q - %v", command, err)
+		}
+	}
+}
+
+func Test_PrinterColors(t *testing.T) {
+	colored := "\x1b[31mERROR\x1b[0m failed\n"
+
+	for _, test := range []struct {
+		name     string
+		terminal bool
+		commands []string
+		expected string
+	}{
+		{"kept on a terminal", true, nil, colored},
+		{"stripped otherwise", false, nil, "ERROR failed\n"},
+		{"stripped when turned off", true, []string{"set colors off"}, "ERROR failed\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			p := NewPrinter(&out, config.Default(), test.terminal)
+			for _, command := range test.commands {
+				p.Execute(command)
+			}
+
+			p.Print(strings.NewReader(colored))
+			if out.String() != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+func Test_PrinterLongLines(t *testing.T) {
+	cfg := config.Default()
+	cfg.View.MaxLineLength = 10
+	line := strings.Repeat("x", 100) + "\n"
+
+	var out strings.Builder
+	NewPrinter(&out, cfg, false).Print(strings.NewReader(line))
+
+	// the maximum length only applies to the view, lines are written whole
+	if out.String() != line {
+		t.Errorf("expected %q, got %q", line, out.String())
+	}
+}
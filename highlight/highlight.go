@@ -0,0 +1,288 @@
+package highlight
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/utils"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// colorNames - colors that can be referred by name in rules and config
+var colorNames = map[string]lipgloss.Color{
+	"black":   lipgloss.Color("0"),
+	"red":     lipgloss.Color("1"),
+	"green":   lipgloss.Color("2"),
+	"yellow":  lipgloss.Color("3"),
+	"blue":    lipgloss.Color("4"),
+	"magenta": lipgloss.Color("5"),
+	"cyan":    lipgloss.Color("6"),
+	"white":   lipgloss.Color("7"),
+	"grey":    lipgloss.Color("8"),
+	"gray":    lipgloss.Color("8"),
+}
+
+// Rule - styles the parts of a line matching the pattern.
+type Rule struct {
+	Pattern *regexp.Regexp
+	Style   lipgloss.Style
+	Source  string // rule as written by the user
+}
+
+// Rules - highlight rules applied in order. Where rules overlap, the later
+// rule takes precedence for the attributes it sets and the earlier rules still
+// apply the rest, e.g. a bold rule and a later yellow rule give bold yellow.
+type Rules []Rule
+
+// NewRule - creates a rule from a regex `pattern` and a `style` spec. Each spec
+// item is one of:
+//   - `<color>` or `fg=<color>` - foreground color
+//   - `bg=<color>`              - background color
+//   - `bold`, `underline`, `italic`, `reverse`
+//
+// Colors are names (e.g. `yellow`), ANSI 256 numbers or hex values.
+func NewRule(pattern string, style ...string) (Rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid pattern %q - %w", pattern, err)
+	}
+
+	if len(style) == 0 {
+		return Rule{}, errors.New("missing style for rule")
+	}
+
+	s := lipgloss.NewStyle()
+	for _, item := range style {
+		if s, err = applySpec(s, item); err != nil {
+			return Rule{}, err
+		}
+	}
+
+	return Rule{
+		Pattern: re,
+		Style:   s,
+		Source:  quote(pattern) + " " + strings.Join(style, " "),
+	}, nil
+}
+
+// ParseRule - parses a rule written as `"<pattern>" <style>...`.
+func ParseRule(line string) (Rule, error) {
+	args, err := utils.SplitArgs(line)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	if len(args) == 0 {
+		return Rule{}, errors.New("missing pattern for rule")
+	}
+
+	return NewRule(args[0], args[1:]...)
+}
+
+// Apply - styles the parts of the `line` matched by the rules. Matching is
+// done on the text without its escape sequences, and the escape sequences of
+// the line are kept so that its own colors are restored after a highlight.
+func (r Rules) Apply(line string) string {
+	if len(r) == 0 {
+		return line
+	}
+
+	tokens := utils.TokenizeANSI(line)
+	spans := r.match(plainText(tokens))
+	if len(spans) == 0 {
+		return line
+	}
+
+	var (
+		out    strings.Builder
+		active string // SGR sequences of the line in effect
+		offset int    // offset of the current token within the plain text
+	)
+
+	for _, token := range tokens {
+		if token.Escape {
+			out.WriteString(token.Text)
+			switch {
+			case utils.IsSGRReset(token.Text):
+				active = ""
+			case utils.IsSGR(token.Text):
+				active += token.Text
+			}
+			continue
+		}
+
+		for start := 0; start < len(token.Text); {
+			style, end, ok := spans.at(offset + start)
+			end = min(end-offset, len(token.Text))
+
+			if ok {
+				out.WriteString(style.Render(token.Text[start:end]))
+				out.WriteString(active)
+			} else {
+				out.WriteString(token.Text[start:end])
+			}
+			start = end
+		}
+		offset += len(token.Text)
+	}
+
+	return out.String()
+}
+
+// WithSearch - returns the rules with one styling the matches of the
+// `pattern` in the `style` on top, or the rules as they are if `pattern` is
+// nil. The rules are not modified.
+func (r Rules) WithSearch(pattern *regexp.Regexp, style lipgloss.Style) Rules {
+	if pattern == nil {
+		return r
+	}
+
+	search := Rule{Pattern: pattern, Style: style}
+	return append(r[:len(r):len(r)], search)
+}
+
+// ParseColor - parses a color name (e.g. `yellow`), an ANSI 256 color number
+// (e.g. `63`) or a hex color (e.g. `#ff8800`).
+func ParseColor(name string) (lipgloss.Color, error) {
+	name = strings.ToLower(name)
+
+	if color, ok := colorNames[name]; ok {
+		return color, nil
+	}
+
+	if n, err := strconv.Atoi(name); err == nil && n >= 0 && n <= 255 {
+		return lipgloss.Color(name), nil
+	}
+
+	if len(name) == 7 && name[0] == '#' {
+		if _, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return lipgloss.Color(name), nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown color %q", name)
+}
+
+// ----------------------- PRIVATE
+
+// span - a range of the plain text with the style it is highlighted with.
+type span struct {
+	start, end int
+	style      lipgloss.Style
+	styled     bool
+}
+
+type spans []span
+
+// match - splits the `plain` text into spans with the composed style of all
+// the rules matching each of them. Returns nil if no rule matches.
+func (r Rules) match(plain string) spans {
+	type hit struct{ start, end, rule int }
+
+	var hits []hit
+	bounds := []int{0, len(plain)}
+	for i, rule := range r {
+		for _, m := range rule.Pattern.FindAllStringIndex(plain, -1) {
+			if m[0] == m[1] {
+				continue
+			}
+			hits = append(hits, hit{m[0], m[1], i})
+			bounds = append(bounds, m[0], m[1])
+		}
+	}
+
+	if len(hits) == 0 {
+		return nil
+	}
+
+	sort.Ints(bounds)
+
+	var result spans
+	for i := 1; i < len(bounds); i += 1 {
+		start, end := bounds[i-1], bounds[i]
+		if start == end {
+			continue
+		}
+
+		current := span{start: start, end: end}
+		for j := len(hits) - 1; j >= 0; j -= 1 {
+			h := hits[j]
+			if h.start <= start && end <= h.end {
+				if current.styled {
+					current.style = current.style.Inherit(r[h.rule].Style)
+				} else {
+					current.style = r[h.rule].Style
+					current.styled = true
+				}
+			}
+		}
+		result = append(result, current)
+	}
+
+	return result
+}
+
+// at - returns the style of the span holding `offset`, where that span ends
+// and if it is styled at all.
+func (s spans) at(offset int) (lipgloss.Style, int, bool) {
+	i := sort.Search(len(s), func(i int) bool {
+		return s[i].end > offset
+	})
+	if i == len(s) {
+		return lipgloss.Style{}, offset + 1, false
+	}
+	return s[i].style, s[i].end, s[i].styled
+}
+
+// applySpec - applies a single style spec item to the style.
+func applySpec(s lipgloss.Style, item string) (lipgloss.Style, error) {
+	switch strings.ToLower(item) {
+	case "bold":
+		return s.Bold(true), nil
+	case "underline":
+		return s.Underline(true), nil
+	case "italic":
+		return s.Italic(true), nil
+	case "reverse":
+		return s.Reverse(true), nil
+	}
+
+	key, value, found := strings.Cut(item, "=")
+	if !found {
+		key, value = "fg", item
+	}
+
+	color, err := ParseColor(value)
+	if err != nil {
+		return s, err
+	}
+
+	switch strings.ToLower(key) {
+	case "fg":
+		return s.Foreground(color), nil
+	case "bg":
+		return s.Background(color), nil
+	default:
+		return s, fmt.Errorf("unknown style %q", item)
+	}
+}
+
+// quote - quotes the pattern the way `utils.SplitArgs` reads it back.
+func quote(pattern string) string {
+	return `"` + strings.ReplaceAll(pattern, `"`, `\"`) + `"`
+}
+
+// plainText - joins the text tokens of a line.
+func plainText(tokens []utils.AnsiToken) string {
+	var plain strings.Builder
+	for _, token := range tokens {
+		if !token.Escape {
+			plain.WriteString(token.Text)
+		}
+	}
+	return plain.String()
+}
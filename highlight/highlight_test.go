@@ -0,0 +1,107 @@
+package highlight
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func init() {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+}
+
+func Test_RulesApplyTableDriven(t *testing.T) {
+	yellow := "\x1b[33m"
+	bold := "\x1b[1m"
+	reset := "\x1b[0m"
+
+	for _, test := range []struct {
+		name     string
+		rules    []string
+		line     string
+		expected string
+	}{
+		{
+			name:     "no match",
+			rules:    []string{`"id=\d+" yellow`},
+			line:     "nothing here",
+			expected: "nothing here",
+		},
+		{
+			name:     "single match",
+			rules:    []string{`"id=\d+" yellow`},
+			line:     "user id=42 logged in",
+			expected: "user " + yellow + "id=42" + reset + " logged in",
+		},
+		{
+			name:     "match across an escape code of the line",
+			rules:    []string{`"id=\d+" yellow`},
+			line:     "\x1b[31mid=\x1b[32m42\x1b[0m!",
+			expected: "\x1b[31m" + yellow + "id=" + reset + "\x1b[31m" + "\x1b[32m" + yellow + "42" + reset + "\x1b[31m\x1b[32m" + "\x1b[0m!",
+		},
+		{
+			name:     "layered rules",
+			rules:    []string{`"a+b" bold`, `"b" yellow`},
+			line:     "aab",
+			expected: bold + "aa" + reset + "\x1b[1;33m" + "b" + reset,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var rules Rules
+			for _, each := range test.rules {
+				rule, err := ParseRule(each)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rules = append(rules, rule)
+			}
+
+			actual := rules.Apply(test.line)
+			if actual != test.expected {
+				t.Errorf("expected equal\n\texpected:\t%q\n\tactual:\t\t%q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_ParseRuleErrors(t *testing.T) {
+	for _, line := range []string{
+		``,
+		`"id=\d+"`,
+		`"id=(" yellow`,
+		`"id" notacolor`,
+		`"id" size=3`,
+		`"id yellow`,
+	} {
+		if _, err := ParseRule(line); err == nil {
+			t.Errorf("expected error for rule %q", line)
+		}
+	}
+}
+
+func Test_ParseColorTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected lipgloss.Color
+		fails    bool
+	}{
+		{"Yellow", lipgloss.Color("3"), false},
+		{"gray", lipgloss.Color("8"), false},
+		{"63", lipgloss.Color("63"), false},
+		{"#FF8800", lipgloss.Color("#ff8800"), false},
+		{"256", "", true},
+		{"#ff88", "", true},
+		{"orange", "", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ParseColor(test.name)
+			if (err != nil) != test.fails {
+				t.Fatalf("expected failure %v, got error %v", test.fails, err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
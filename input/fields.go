@@ -0,0 +1,28 @@
+package input
+
+import (
+	"sort"
+
+	"github.com/SpandanBG/logctrl/structured"
+	"github.com/SpandanBG/logctrl/utils"
+)
+
+// Fields - returns the field names of the `lines` parsed by the `parsers`,
+// sorted, the way the table and the sort find them. Escape codes are ignored.
+func Fields(lines []string, parsers structured.Parsers) []string {
+	seen := map[string]bool{}
+
+	for _, line := range lines {
+		fields, _ := parsers.Parse(utils.StripANSI(utils.ApplyCarriageReturns(line)))
+		for _, field := range fields {
+			seen[field.Key] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,163 @@
+package input
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	historyDirName  = "logctrl"
+	historyFileName = "history"
+	historyLimit    = 1000
+)
+
+// History - the commands submitted from the prompt, oldest first.
+type History interface {
+	Add(string)
+	Len() int
+	At(int) string
+	Search(query string, from int) (int, bool)
+}
+
+type history struct {
+	mu      sync.Mutex
+	path    string   // file the entries are appended to, empty to keep them in memory
+	entries []string // oldest first
+}
+
+// HistoryPath - returns the path of the history file in the user's cache
+// directory.
+func HistoryPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, historyDirName, historyFileName)
+}
+
+// NewHistory - loads the history kept at `path`. An empty `path` keeps it in
+// memory only. On error the returned history is still usable in memory.
+func NewHistory(path string) (History, error) {
+	h := &history{path: path}
+	if len(path) == 0 {
+		return h, nil
+	}
+
+	if err := h.load(); err != nil {
+		h.path = ""
+		return h, err
+	}
+	return h, nil
+}
+
+// Add - appends the `line` to the history, skipping blank lines and repeats of
+// the last entry.
+func (h *history) Add(line string) {
+	line = strings.TrimSpace(line)
+	if len(line) == 0 || strings.Contains(line, "\n") {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+
+	h.entries = append(h.entries, line)
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+		h.save()
+		return
+	}
+
+	h.append(line)
+}
+
+func (h *history) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// At - returns the `i`th entry, 0 being the oldest.
+func (h *history) At(i int) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.entries[i]
+}
+
+// Search - returns the newest entry at or before `from` containing the
+// `query`.
+func (h *history) Search(query string, from int) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := min(from, len(h.entries)-1); i >= 0; i -= 1 {
+		if strings.Contains(h.entries[i], query) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// ----------------------- PRIVATE
+
+func (h *history) load() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); len(line) > 0 {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+		h.save()
+	}
+	return nil
+}
+
+// append - adds the `line` at the end of the history file.
+func (h *history) append(line string) {
+	if len(h.path) == 0 {
+		return
+	}
+
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	f.WriteString(line + "\n")
+}
+
+// save - rewrites the history file with the entries kept.
+func (h *history) save() {
+	if len(h.path) == 0 {
+		return
+	}
+
+	os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,62 @@
+package input
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func Test_History(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history")
+
+	h, err := NewHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"filter a", "  ", "search b", "search b", "goto 1", "filter c"} {
+		h.Add(line)
+	}
+
+	reopened, err := NewHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"filter a", "search b", "goto 1", "filter c"}
+	if reopened.Len() != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), reopened.Len())
+	}
+	for i, line := range expected {
+		if actual := reopened.At(i); actual != line {
+			t.Errorf("entry %d: expected %q, got %q", i, line, actual)
+		}
+	}
+
+	for _, test := range []struct {
+		query    string
+		from     int
+		expected int
+	}{
+		{"filter", 3, 3},
+		{"filter", 2, 0},
+		{"", 10, 3},
+		{"nope", 3, -1},
+	} {
+		if actual, _ := reopened.Search(test.query, test.from); actual != test.expected {
+			t.Errorf("search %q from %d: expected %d, got %d", test.query, test.from, test.expected, actual)
+		}
+	}
+}
+
+func Test_HistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h, _ := NewHistory(path)
+	for i := 0; i < historyLimit+5; i += 1 {
+		h.Add(string(rune('a'+i%26)) + string(rune('0'+i%10)))
+	}
+
+	reopened, _ := NewHistory(path)
+	if reopened.Len() != historyLimit {
+		t.Errorf("expected %d entries, got %d", historyLimit, reopened.Len())
+	}
+}
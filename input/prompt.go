@@ -1 +1,224 @@
 package input
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/SpandanBG/logctrl/cmd"
+)
+
+// Completer - completes the last word of a command line.
+type Completer struct {
+	fields  func() []string // field names of the logs read so far
+	filters []string        // names of the saved filters
+}
+
+// NewCompleter - creates a completer offering the `fields` of the logs and the
+// saved `filters` next to the command and option names.
+func NewCompleter(fields func() []string, filters map[string]string) Completer {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, "@"+name)
+	}
+	sort.Strings(names)
+
+	return Completer{fields: fields, filters: names}
+}
+
+// Complete - completes the last word of the `line` as far as the candidates
+// agree. Returns the completed line and the candidates when more than one is
+// left.
+func (c Completer) Complete(line string) (string, []string) {
+	words := strings.Fields(line)
+	if len(words) == 0 || len(strings.TrimRightFunc(line, unicode.IsSpace)) < len(line) {
+		// a new word is started after the spaces
+		words = append(words, "")
+	}
+
+	last := words[len(words)-1]
+	head := line[:len(line)-len(last)]
+	candidates := c.candidates(words[:len(words)-1], last)
+
+	switch len(candidates) {
+	case 0:
+		return line, nil
+	case 1:
+		return head + closeWord(candidates[0]), nil
+	}
+
+	return head + commonPrefix(candidates), candidates
+}
+
+// Hint - returns the syntax of the command being typed in the `line`.
+func Hint(line string) string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return ""
+	}
+
+	spec, ok := cmd.Lookup(strings.ToLower(words[0]))
+	if !ok {
+		return ""
+	}
+	return spec.Usage + " - " + spec.Help
+}
+
+// ----------------------- PRIVATE
+
+// candidates - returns the words starting with `word` that can follow the
+// `before` words.
+func (c Completer) candidates(before []string, word string) []string {
+	if len(before) == 0 {
+		return withPrefix(commandNames(), word)
+	}
+
+	switch strings.ToLower(before[0]) {
+	case "set":
+		return c.optionCandidates(before[1:], word)
+	case "save":
+		return pathCandidates(word)
+	case "goto":
+		return withPrefix([]string{"top", "end"}, word)
+	case "filter":
+		words := append([]string{"-v"}, c.filters...)
+		return withPrefix(append(words, c.fieldWords()...), word)
+	case "search":
+		return withPrefix(c.fieldWords(), word)
+	case "highlight":
+		if len(before) == 1 {
+			return withPrefix(c.fieldWords(), word)
+		}
+	case "columns":
+		return withPrefix(c.fieldNames(), word)
+	case "query":
+		paths := make([]string, 0)
+		for _, field := range c.fieldNames() {
+			paths = append(paths, "."+field)
+		}
+		return withPrefix(paths, word)
+	case "sort":
+		switch len(before) {
+		case 1:
+			return withPrefix(c.fieldNames(), word)
+		case 2:
+			return withPrefix([]string{"asc", "desc"}, word)
+		}
+	}
+
+	return nil
+}
+
+func (c Completer) optionCandidates(before []string, word string) []string {
+	if len(before) == 0 {
+		names := make([]string, len(cmd.Options))
+		for i, option := range cmd.Options {
+			names[i] = option.Name
+		}
+		return withPrefix(names, word)
+	}
+
+	option, ok := cmd.LookupOption(before[0])
+	if !ok || len(before) != 1 {
+		return nil
+	}
+
+	switch option.Kind {
+	case cmd.BoolOption:
+		return withPrefix([]string{"on", "off"}, word)
+	case cmd.ChoiceOption:
+		return withPrefix(option.Choices, word)
+	}
+	return nil
+}
+
+// fieldNames - returns the fields of the logs.
+func (c Completer) fieldNames() []string {
+	if c.fields == nil {
+		return nil
+	}
+	return c.fields()
+}
+
+// fieldWords - returns the fields of the logs as `field=` words.
+func (c Completer) fieldWords() []string {
+	fields := c.fieldNames()
+	words := make([]string, len(fields))
+	for i, field := range fields {
+		words[i] = field + "="
+	}
+	return words
+}
+
+// pathCandidates - returns the files and directories starting with `word`,
+// directories ending with a slash.
+func pathCandidates(word string) []string {
+	dir, base := filepath.Split(word)
+
+	root := dir
+	if len(root) == 0 {
+		root = "."
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		paths = append(paths, dir+name)
+	}
+	return paths
+}
+
+func commandNames() []string {
+	names := make([]string, len(cmd.Specs))
+	for i, spec := range cmd.Specs {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+func withPrefix(words []string, prefix string) []string {
+	var matched []string
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			matched = append(matched, word)
+		}
+	}
+	return matched
+}
+
+// closeWord - ends a completed word with a space unless more is expected
+// after it.
+func closeWord(word string) string {
+	if strings.HasSuffix(word, "/") || strings.HasSuffix(word, "=") {
+		return word
+	}
+	return word + " "
+}
+
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		same := 0
+		for _, r := range word {
+			if same == len(prefix) || prefix[same] != r {
+				break
+			}
+			same += 1
+		}
+		prefix = prefix[:same]
+	}
+	return string(prefix)
+}
@@ -0,0 +1,118 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SpandanBG/logctrl/structured"
+)
+
+func Test_CompleteTableDriven(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "out.log"), nil, 0o644)
+	os.Mkdir(filepath.Join(dir, "logs"), 0o755)
+
+	completer := NewCompleter(
+		func() []string { return []string{"latency", "level", "msg"} },
+		map[string]string{"errors": "ERROR"},
+	)
+
+	for _, test := range []struct {
+		line       string
+		expected   string
+		candidates string
+	}{
+		{"", "", "filter search save goto highlight set columns sort query"},
+		{"fi", "filter ", ""},
+		{"s", "s", "search save set sort"},
+		{"se", "se", "search set"},
+		{"set n", "set numbers ", ""},
+		{"set numbers o", "set numbers o", "on off"},
+		{"set copy ", "set copy ", "text raw json"},
+		{"set numbers\t", "set numbers\to", "on off"},
+		{"sort latency\td", "sort latency\tdesc ", ""},
+		{"set copy j", "set copy json ", ""},
+		{"goto e", "goto end ", ""},
+		{"filter @", "filter @errors ", ""},
+		{"filter l", "filter l", "latency= level="},
+		{"filter le", "filter level=", ""},
+		{"search m", "search msg=", ""},
+		{"highlight x ", "highlight x ", ""},
+		{"columns level m", "columns level msg ", ""},
+		{"sort la", "sort latency ", ""},
+		{"sort latency d", "sort latency desc ", ""},
+		{"query .la", "query .latency ", ""},
+		{"query .level == 1 and .m", "query .level == 1 and .msg ", ""},
+		{"save " + dir + "/o", "save " + dir + "/out.log ", ""},
+		{"save " + dir + "/l", "save " + dir + "/logs/", ""},
+		{"nope x", "nope x", ""},
+	} {
+		t.Run(test.line, func(t *testing.T) {
+			line, candidates := completer.Complete(test.line)
+			if line != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, line)
+			}
+			if actual := strings.Join(candidates, " "); actual != test.candidates {
+				t.Errorf("expected candidates %q, got %q", test.candidates, actual)
+			}
+		})
+	}
+}
+
+func Test_Hint(t *testing.T) {
+	if hint := Hint("goto 1"); !strings.HasPrefix(hint, "goto <line>") {
+		t.Errorf("unexpected hint %q", hint)
+	}
+	if hint := Hint("nope"); hint != "" {
+		t.Errorf("expected no hint, got %q", hint)
+	}
+}
+
+func Test_FieldsTableDriven(t *testing.T) {
+	lines := []string{
+		`{"level":"info","msg":"started","nested":{"a":1}}`,
+		`time=12:00 level=warn user.id=7 msg="slow request"`,
+		`plain line = not a field`,
+		"\x1b[32mcolor\x1b[0m=green",
+		`12:00 [db] connected`,
+	}
+	custom, _ := structured.NewParser("regex", `^(?P<at>\S+) \[(?P<module>\w+)\]`)
+
+	for _, test := range []struct {
+		name     string
+		parsers  structured.Parsers
+		expected string
+	}{
+		{"json and logfmt", structured.Default(), "color level msg nested time user.id"},
+		{"configured parsers only", structured.Parsers{custom}, "at module"},
+		{"no parsers", nil, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fields := Fields(lines, test.parsers)
+			if actual := strings.Join(fields, " "); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_CommonPrefixTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		words    []string
+		expected string
+	}{
+		{[]string{"level", "latency"}, "l"},
+		{[]string{"durée", "durèe"}, "dur"},
+		{[]string{"日志", "日期"}, "日"},
+		{[]string{"msg"}, "msg"},
+		{[]string{"a", "b"}, ""},
+	} {
+		t.Run(strings.Join(test.words, " "), func(t *testing.T) {
+			if actual := commonPrefix(test.words); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
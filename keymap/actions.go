@@ -0,0 +1,97 @@
+package keymap
+
+// Names of the registered actions.
+const (
+	Quit             = "quit"
+	TogglePrompt     = "toggle-prompt"
+	ToggleBookmarks  = "toggle-bookmarks"
+	ScrollUp         = "scroll-up"
+	ScrollDown       = "scroll-down"
+	PageUp           = "page-up"
+	PageDown         = "page-down"
+	Top              = "top"
+	Follow           = "follow"
+	Bookmark         = "bookmark"
+	BookmarkNote     = "bookmark-note"
+	NextBookmark     = "next-bookmark"
+	PrevBookmark     = "prev-bookmark"
+	SearchNext       = "search-next"
+	SearchPrev       = "search-prev"
+	ToggleWrap       = "toggle-wrap"
+	ScrollLeft       = "scroll-left"
+	ScrollRight      = "scroll-right"
+	ToggleColors     = "toggle-colors"
+	ToggleFold       = "toggle-fold"
+	ToggleFoldAll    = "toggle-fold-all"
+	ToggleCollapse   = "toggle-collapse"
+	ToggleSummary    = "toggle-summary"
+	ToggleTable      = "toggle-table"
+	ToggleDetail     = "toggle-detail"
+	ScrollDetailUp   = "scroll-detail-up"
+	ScrollDetailDown = "scroll-detail-down"
+	ToggleSelect     = "toggle-select"
+	Yank             = "yank"
+	CancelSelect     = "cancel-select"
+	ToggleDebug      = "toggle-debug"
+	Submit           = "submit"
+	ClosePrompt      = "close-prompt"
+	HistoryPrev      = "history-prev"
+	HistoryNext      = "history-next"
+	HistorySearch    = "history-search"
+	Complete         = "complete"
+	JumpToBookmark   = "jump-to-bookmark"
+	EditBookmark     = "edit-bookmark"
+	DeleteBookmark   = "delete-bookmark"
+	CloseBookmarks   = "close-bookmarks"
+	FilterTemplate   = "filter-template"
+	SortTemplates    = "sort-templates"
+	CloseSummary     = "close-summary"
+)
+
+// Actions - every action that can be bound, with its default keys.
+var Actions = []Action{
+	{Quit, []Context{LogView}, []string{"q", "ctrl+c", "ctrl+d"}, "Quit"},
+	{TogglePrompt, []Context{LogView}, []string{"tab"}, "Prompt"},
+	{ToggleBookmarks, []Context{LogView}, []string{"b"}, "Bookmarks"},
+	{ScrollUp, []Context{LogView, Bookmarks, Summary}, []string{"up", "k"}, "Up"},
+	{ScrollDown, []Context{LogView, Bookmarks, Summary}, []string{"down", "j"}, "Down"},
+	{PageUp, []Context{LogView}, []string{"pgup", "ctrl+b"}, "Page up"},
+	{PageDown, []Context{LogView}, []string{"pgdown", "ctrl+f"}, "Page down"},
+	{Top, []Context{LogView}, []string{"home", "g g"}, "Top"},
+	{Follow, []Context{LogView}, []string{"end", "G"}, "Follow"},
+	{Bookmark, []Context{LogView}, []string{"m"}, "Bookmark"},
+	{BookmarkNote, []Context{LogView}, []string{"M"}, "Bookmark with note"},
+	{NextBookmark, []Context{LogView}, []string{"]"}, "Next bookmark"},
+	{PrevBookmark, []Context{LogView}, []string{"["}, "Previous bookmark"},
+	{SearchNext, []Context{LogView}, []string{"n"}, "Next match"},
+	{SearchPrev, []Context{LogView}, []string{"N"}, "Previous match"},
+	{ToggleWrap, []Context{LogView}, []string{"w"}, "Wrap"},
+	{ScrollLeft, []Context{LogView}, []string{"left", "h"}, "Left"},
+	{ScrollRight, []Context{LogView}, []string{"right", "l"}, "Right"},
+	{ToggleColors, []Context{LogView}, []string{"c"}, "Colors"},
+	{ToggleFold, []Context{LogView}, []string{"z"}, "Fold"},
+	{ToggleFoldAll, []Context{LogView}, []string{"Z"}, "Fold all"},
+	{ToggleCollapse, []Context{LogView}, []string{"r"}, "Collapse repeats"},
+	{ToggleSummary, []Context{LogView}, []string{"s"}, "Summary"},
+	{ToggleTable, []Context{LogView}, []string{"t"}, "Table"},
+	{ToggleDetail, []Context{LogView}, []string{"enter"}, "Detail"},
+	{ScrollDetailUp, []Context{LogView}, []string{"K"}, "Detail up"},
+	{ScrollDetailDown, []Context{LogView}, []string{"J"}, "Detail down"},
+	{ToggleSelect, []Context{LogView}, []string{"v", "V"}, "Select lines"},
+	{Yank, []Context{LogView}, []string{"y"}, "Copy"},
+	{CancelSelect, []Context{LogView}, []string{"esc"}, "Cancel selection"},
+	{ToggleDebug, []Context{LogView}, []string{"D"}, "Debug overlay"},
+	{Submit, []Context{Prompt}, []string{"enter"}, "Submit"},
+	{ClosePrompt, []Context{Prompt}, []string{"esc"}, "Close"},
+	{HistoryPrev, []Context{Prompt}, []string{"up"}, "Previous command"},
+	{HistoryNext, []Context{Prompt}, []string{"down"}, "Next command"},
+	{HistorySearch, []Context{Prompt}, []string{"ctrl+r"}, "Search history"},
+	{Complete, []Context{Prompt}, []string{"tab"}, "Complete"},
+	{JumpToBookmark, []Context{Bookmarks}, []string{"enter"}, "Jump"},
+	{EditBookmark, []Context{Bookmarks}, []string{"e"}, "Edit note"},
+	{DeleteBookmark, []Context{Bookmarks}, []string{"d"}, "Delete"},
+	{CloseBookmarks, []Context{Bookmarks}, []string{"esc", "b"}, "Close"},
+	{FilterTemplate, []Context{Summary}, []string{"enter"}, "Filter"},
+	{SortTemplates, []Context{Summary}, []string{"o"}, "Sort"},
+	{CloseSummary, []Context{Summary}, []string{"esc", "s"}, "Close"},
+}
@@ -0,0 +1,208 @@
+package keymap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Context - the part of the UI a key is pressed in. Each context has its own
+// bindings so that the same key can do different things in each.
+type Context string
+
+const (
+	LogView   Context = "log"
+	Prompt    Context = "prompt"
+	Bookmarks Context = "bookmarks"
+	Summary   Context = "summary"
+)
+
+// Action - a named action that keys can be bound to.
+type Action struct {
+	Name     string
+	Contexts []Context
+	Keys     []string // default key sequences
+	Help     string
+}
+
+// ActionMsg - sent to the components in place of the key(s) bound to an
+// action.
+type ActionMsg struct {
+	Name string
+}
+
+// Keymap - resolves keys and key sequences to actions. A keymap is a value, the
+// updated keymap returned by `Resolve` must be kept to follow sequences.
+type Keymap struct {
+	bindings map[Context]map[string]string // sequence -> action
+	prefixes map[Context]map[string]bool   // incomplete sequences
+	keys     map[string][]string           // action -> sequences
+	pending  string                        // keys of the incomplete sequence
+}
+
+// Lookup - returns the registered action with the `name`.
+func Lookup(name string) (Action, bool) {
+	for _, action := range Actions {
+		if action.Name == name {
+			return action, true
+		}
+	}
+	return Action{}, false
+}
+
+// ParseSequence - parses a key sequence into its keys. Keys are separated by
+// spaces and named as bubbletea names them (e.g. `ctrl+c`, `pgup`, `enter`). A
+// word which is not a key name stands for its characters typed in order, so
+// `gg` is the same as `g g`.
+func ParseSequence(sequence string) ([]string, error) {
+	var keys []string
+	for _, word := range strings.Fields(sequence) {
+		if utf8.RuneCountInString(word) == 1 || strings.Contains(word, "+") || keyNames[word] {
+			keys = append(keys, word)
+			continue
+		}
+
+		for _, r := range word {
+			keys = append(keys, string(r))
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+	return keys, nil
+}
+
+// New - creates a keymap of all the registered actions. The `overrides` hold
+// key sequences by action name that replace the default keys of the action.
+// Returns an error if an action is unknown, a sequence is invalid or a
+// sequence is bound to more than one action in a context.
+func New(overrides map[string][]string) (Keymap, error) {
+	k := Keymap{
+		bindings: map[Context]map[string]string{},
+		prefixes: map[Context]map[string]bool{},
+		keys:     map[string][]string{},
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		if _, ok := Lookup(name); !ok {
+			errs = append(errs, fmt.Sprintf("unknown action %q", name))
+		}
+	}
+
+	for _, action := range Actions {
+		sequences := action.Keys
+		if keys, ok := overrides[action.Name]; ok {
+			sequences = keys
+		}
+
+		for _, sequence := range sequences {
+			keys, err := ParseSequence(sequence)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", action.Name, err))
+				continue
+			}
+
+			seq := strings.Join(keys, " ")
+			k.keys[action.Name] = append(k.keys[action.Name], seq)
+			for _, ctx := range action.Contexts {
+				if err := k.bind(ctx, seq, action.Name); err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return k, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return k, nil
+}
+
+// Resolve - feeds the `key` pressed in the context `ctx`. Returns the action
+// bound to the completed sequence, if any, and whether the key was consumed,
+// either by an action or as part of an incomplete sequence. Keys that are not
+// consumed should be handled by the component as typed input.
+func (k Keymap) Resolve(ctx Context, key string) (Keymap, string, bool) {
+	sequence := key
+	if len(k.pending) > 0 {
+		sequence = k.pending + " " + key
+	}
+
+	if action, ok := k.bindings[ctx][sequence]; ok {
+		k.pending = ""
+		return k, action, true
+	}
+
+	if k.prefixes[ctx][sequence] {
+		k.pending = sequence
+		return k, "", true
+	}
+
+	// an incomplete sequence was broken, try the key on its own
+	if len(k.pending) > 0 {
+		k.pending = ""
+		return k.Resolve(ctx, key)
+	}
+
+	return k, "", false
+}
+
+// Keys - returns the key sequences bound to the action.
+func (k Keymap) Keys(action string) []string {
+	return k.keys[action]
+}
+
+// Pending - returns the keys typed so far of an incomplete sequence.
+func (k Keymap) Pending() string {
+	return k.pending
+}
+
+// ----------------------- PRIVATE
+
+// bind - binds the sequence to the action in the context. A sequence can't be
+// bound twice nor be the start of another sequence, as the longer one could
+// never be typed.
+func (k Keymap) bind(ctx Context, seq, action string) error {
+	if k.bindings[ctx] == nil {
+		k.bindings[ctx] = map[string]string{}
+		k.prefixes[ctx] = map[string]bool{}
+	}
+
+	if other, ok := k.bindings[ctx][seq]; ok && other != action {
+		return fmt.Errorf("%q is bound to both %q and %q in %s", seq, other, action, ctx)
+	}
+	if k.prefixes[ctx][seq] {
+		return fmt.Errorf("%q of %q is the start of another sequence in %s", seq, action, ctx)
+	}
+
+	keys := strings.Split(seq, " ")
+	for i := 1; i < len(keys); i += 1 {
+		prefix := strings.Join(keys[:i], " ")
+		if other, ok := k.bindings[ctx][prefix]; ok {
+			return fmt.Errorf("%q of %q starts with %q of %q in %s", seq, action, prefix, other, ctx)
+		}
+		k.prefixes[ctx][prefix] = true
+	}
+
+	k.bindings[ctx][seq] = action
+	return nil
+}
+
+// keyNames - multi character key names of bubbletea
+var keyNames = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true,
+	"pgup": true, "pgdown": true, "home": true, "end": true,
+	"enter": true, "tab": true, "esc": true, "space": true,
+	"backspace": true, "delete": true, "insert": true,
+	"f1": true, "f2": true, "f3": true, "f4": true, "f5": true, "f6": true,
+	"f7": true, "f8": true, "f9": true, "f10": true, "f11": true, "f12": true,
+}
@@ -0,0 +1,112 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ParseSequenceTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		sequence string
+		expected string
+	}{
+		{"q", "q"},
+		{"gg", "g g"},
+		{"g g", "g g"},
+		{"ctrl+x ctrl+s", "ctrl+x ctrl+s"},
+		{"pgup", "pgup"},
+		{"  zz  enter ", "z z enter"},
+	} {
+		t.Run(test.sequence, func(t *testing.T) {
+			keys, err := ParseSequence(test.sequence)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := strings.Join(keys, " "); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+
+	if _, err := ParseSequence("   "); err == nil {
+		t.Error("expected error for empty sequence")
+	}
+}
+
+func Test_ResolveTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		overrides map[string][]string
+		ctx       Context
+		keys      []string
+		actions   []string // action after each key, "-" for unconsumed
+	}{
+		{
+			name:    "single key",
+			ctx:     LogView,
+			keys:    []string{"q"},
+			actions: []string{Quit},
+		},
+		{
+			name:    "sequence",
+			ctx:     LogView,
+			keys:    []string{"g", "g"},
+			actions: []string{"", Top},
+		},
+		{
+			name:    "broken sequence falls back to the key",
+			ctx:     LogView,
+			keys:    []string{"g", "q"},
+			actions: []string{"", Quit},
+		},
+		{
+			name:    "same key in other contexts",
+			ctx:     Prompt,
+			keys:    []string{"q", "enter"},
+			actions: []string{"-", Submit},
+		},
+		{
+			name:      "rebound key",
+			overrides: map[string][]string{Quit: {"x"}, Top: {"ctrl+x t"}},
+			ctx:       LogView,
+			keys:      []string{"q", "x", "ctrl+x", "t"},
+			actions:   []string{"-", Quit, "", Top},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			k, err := New(test.overrides)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, key := range test.keys {
+				var action string
+				var consumed bool
+				k, action, consumed = k.Resolve(test.ctx, key)
+				if !consumed {
+					action = "-"
+				}
+				if action != test.actions[i] {
+					t.Errorf("key %q: expected %q, got %q", key, test.actions[i], action)
+				}
+			}
+		})
+	}
+}
+
+func Test_NewErrors(t *testing.T) {
+	for _, test := range []struct {
+		overrides map[string][]string
+		expected  string
+	}{
+		{map[string][]string{"nope": {"x"}}, `unknown action "nope"`},
+		{map[string][]string{Bookmark: {"q"}}, `"q" is bound to both "quit" and "bookmark" in log`},
+		{map[string][]string{Bookmark: {"g"}}, `"g" of "bookmark" is the start of another sequence`},
+		{map[string][]string{Quit: {""}}, `quit: empty key sequence`},
+	} {
+		_, err := New(test.overrides)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("expected error %q, got %v", test.expected, err)
+		}
+	}
+}
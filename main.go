@@ -1,36 +1,78 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
 	"syscall"
 
+	"github.com/SpandanBG/logctrl/config"
+	"github.com/SpandanBG/logctrl/headless"
 	"github.com/SpandanBG/logctrl/reader"
 	"github.com/SpandanBG/logctrl/ui"
 	"github.com/SpandanBG/logctrl/utils"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/creack/pty"
 	"golang.org/x/sys/unix"
 	"golang.org/x/term"
 )
 
 const (
-	ChildEnvVar = "logctrl_child"
+	ChildEnvVar  = "logctrl_child"
+	StatusEnvVar = "logctrl_status"
+
+	// mouseReset - turns off the mouse reporting modes of the terminal
+	mouseReset = ansi.ResetButtonEventMouseMode +
+		ansi.ResetAnyEventMouseMode +
+		ansi.ResetSgrExtMouseMode
+)
+
+var (
+	sessionPath = flag.String("session", "", "session file to record the logs into; an existing session is continued")
+	noTUI       = flag.Bool("no-tui", false, "write the logs to stdout filtered, without the terminal UI; the default when there is no terminal")
+	commands    commandFlags
 )
 
+// commandFlags - the prompt commands given with repeated `-c` flags.
+type commandFlags []string
+
+func (c *commandFlags) String() string {
+	return strings.Join(*c, "; ")
+}
+
+func (c *commandFlags) Set(command string) error {
+	*c = append(*c, command)
+	return nil
+}
+
+func init() {
+	flag.Var(&commands, "c", "prompt command applied in the no-tui mode, e.g. `filter -v debug`; repeatable")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: producer | %[1]s [flags]\n       %[1]s [flags] command [args...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
 // ────────────────────────────────────────────────────────────────────────────────
 // High-level flow
 // ────────────────────────────────────────────────────────────────────────────────
 // 1. First run (the “parent”):
 //   - Creates an anonymous pipe        →  carries log lines from A to B.
+//   - Creates another one              →  carries the exit code of A, when
+//     A is the command given to run.
 //   - Opens /dev/tty in raw mode       →  gets real-time keystrokes.
 //   - Re-execs itself as the “child”,  →  passing the read-end of the pipe in
 //     wrapped in a PTY.                   ExtraFiles and advertising its fd
 //     number via the CHILD env var.
-//   - Pumps three directions:
+//   - Runs A if given as arguments, its output and errors going to the
+//     pipe, or else pumps three directions:
 //     A → pipe → child (log stream)
 //     /dev/tty → PTY master  (user input)
 //     PTY master → stdout     (child’s screen output)
@@ -40,14 +82,34 @@ const (
 //   - Builds a small TUI (tview) that reads the pipe (fd passed by parent)
 //     and still accepts interactive input through the PTY slave.
 //
+// 3. No terminal (or -no-tui):
+//   - No child, the parent writes A’s logs to stdout filtered by the
+//     -c commands instead.
+//
 // ────────────────────────────────────────────────────────────────────────────────
 func main() {
+	flag.Parse()
+
 	// Are we the re-exec’ed child?
 	if len(os.Getenv(ChildEnvVar)) > 0 {
 		startChildProcess()
 		os.Exit(0)
 	}
 
+	// Report config problems before
+	// taking over the terminal.
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logctrl: %v\n", err)
+		os.Exit(1)
+	}
+
+	// No terminal to take over, as in
+	// CI or cron: filter to stdout.
+	if *noTUI || !hasTerminal() {
+		os.Exit(startHeadless(cfg))
+	}
+
 	// Parent cleanup guarantee.
 	// Ensure the upstream producer (A) is killed
 	// when *we* disappear.
@@ -61,15 +123,28 @@ func main() {
 	logReader, logWritter, console, cleanup := setupStreaming()
 	defer cleanup()
 
+	// Pipe for the exit code of A.
+	statusReader, statusWritter, err := os.Pipe()
+	if err != nil {
+		log.Fatalf("unable to create status pipe to child - %v", err)
+	}
+
 	// Re-exec ourselves as the
 	// child wrapped in a PTY.
 	// • CHILD   env advertises fd
 	// • ExtraFiles[0] becomes fd 3
-	ptm := startPTY(logReader)
+	// • ExtraFiles[1] becomes fd 4
+	ptm := startPTY(logReader, statusReader)
 
-	// Pump A’s output (parent’s
-	// stdin) into the pipe → child
-	startDataPump(ptm, logWritter, console)
+	// Run A, or pump A’s output
+	// (parent’s stdin), into the
+	// pipe → child
+	if producer := flag.Args(); len(producer) > 0 {
+		go runProducer(producer, logWritter, statusWritter)
+	} else {
+		statusWritter.Close()
+	}
+	startDataPump(ptm, logWritter, console, flag.NArg() == 0)
 }
 
 // startChildProcess - prepares the stream and launches the app UI.
@@ -81,11 +156,32 @@ func startChildProcess() {
 		log.Fatalf("unable to parse child env var - %v", err)
 	}
 
-	// Get the log feed pipe
-	logFeed := os.NewFile(uintptr(childFd), "logFeed")
+	// Get the log feed pipe, non blocking
+	// for its reading to be stoppable
+	logFeed, err := reader.OpenPipe(childFd, "logFeed")
+	if err != nil {
+		log.Fatalf("unable to open the log feed - %v", err)
+	}
+
+	// Get the status pipe, if passed,
+	// as stoppable as the log feed
+	var status *os.File
+	if statusFd, err := strconv.Atoi(os.Getenv(StatusEnvVar)); err == nil {
+		if status, err = reader.OpenPipe(statusFd, "status"); err != nil {
+			log.Fatalf("unable to open the status pipe - %v", err)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("unable to load config - %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	stream := reader.NewStream(logFeed)
-	app, exit := ui.NewUI(stream)
+	stream := reader.NewStream(logFeed, status, *sessionPath, cfg.Session.Dir, cfg.Session.KeepProgress)
+	app, exit := ui.NewUI(ctx, stream, cfg)
 	defer exit()
 
 	if _, err := app.Run(); err != nil {
@@ -93,6 +189,59 @@ func startChildProcess() {
 	}
 }
 
+// hasTerminal - reports if the UI can be shown: `/dev/tty` can be opened for
+// the keyboard and stdout is a terminal.
+func hasTerminal() bool {
+	console, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	console.Close()
+
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// startHeadless - writes the logs of A, read from stdin or run as the command
+// given, to stdout as the log view would show them after the `-c` commands.
+// Returns the exit code of A if it was run, 2 for an invalid command, 1 if the
+// logs could not be read or written.
+func startHeadless(cfg config.Config) int {
+	printer := headless.NewPrinter(os.Stdout, cfg, term.IsTerminal(int(os.Stdout.Fd())))
+	for _, command := range commands {
+		if err := printer.Execute(command); err != nil {
+			fmt.Fprintf(os.Stderr, "logctrl: %s: %v\n", command, err)
+			return 2
+		}
+	}
+
+	if flag.NArg() == 0 {
+		if err := printer.Print(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "logctrl: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	logReader, logWritter, err := os.Pipe()
+	if err != nil {
+		log.Fatalf("unable to create pipe - %v", err)
+	}
+	statusReader, statusWritter, err := os.Pipe()
+	if err != nil {
+		log.Fatalf("unable to create status pipe - %v", err)
+	}
+
+	go runProducer(flag.Args(), logWritter, statusWritter)
+	if err := printer.Print(logReader); err != nil {
+		fmt.Fprintf(os.Stderr, "logctrl: %v\n", err)
+		return 1
+	}
+
+	code := 0
+	fmt.Fscan(statusReader, &code)
+	return code
+}
+
 // setupStreaming - creates a log feed pipe and prepares the `/dev/tty` as the
 // console for I/O by PTY. Ensures to make `tty` as raw to pass all handing of
 // keyboard signals by child instead of parent.
@@ -126,20 +275,27 @@ func setupStreaming() (
 	}
 
 	// cleanup - on call ensures to restore the terminal to original state on exit.
+	// The mouse reporting turned on by the child is turned off, in case the
+	// child could not do so itself.
 	cleanup = func() {
 		defer term.Restore(int(console.Fd()), oldState)
+		os.Stdout.WriteString(mouseReset)
 	}
 
 	return
 }
 
-// startPTY - starts self in a PTY and passes the required log feed reader file
-// and set required env variables.
-func startPTY(logReader *os.File) (ptm *os.File) {
+// startPTY - starts self in a PTY and passes the required log feed and status
+// reader files and set required env variables.
+func startPTY(logReader, statusReader *os.File) (ptm *os.File) {
 	self := os.Args[0]
-	cmd := exec.Command(self)
-	cmd.Env = append(cmd.Environ(), fmt.Sprintf("%s=%d", ChildEnvVar, logReader.Fd()))
-	cmd.ExtraFiles = append(cmd.ExtraFiles, logReader)
+	cmd := exec.Command(self, os.Args[1:]...)
+	cmd.Env = append(
+		cmd.Environ(),
+		fmt.Sprintf("%s=%d", ChildEnvVar, 3),
+		fmt.Sprintf("%s=%d", StatusEnvVar, 4),
+	)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, logReader, statusReader)
 
 	// Spawn in a fresh PTY;  ptm = PTY master
 	ptm, err := pty.Start(cmd)
@@ -156,25 +312,55 @@ func startPTY(logReader *os.File) (ptm *os.File) {
 	return ptm
 }
 
+// runProducer - runs the `command` with its output and errors written to
+// `logWritter`, then writes its exit code to `statusWritter`. A command that
+// can not be started gets the exit code 127 of shells, the reason being
+// written as a log line.
+func runProducer(command []string, logWritter, statusWritter *os.File) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdout = logWritter
+	cmd.Stderr = logWritter
+
+	code := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else {
+			fmt.Fprintf(logWritter, "logctrl: %v\n", err)
+			code = 127
+		}
+	}
+	logWritter.Close()
+
+	fmt.Fprintf(statusWritter, "%d\n", code)
+	statusWritter.Close()
+}
+
 // startDataPump
-//   - perform writing to `logWritter` from `os.Stdin`.
+//   - perform writing to `logWritter` from `os.Stdin`, if `fromStdin`.
 //   - pumps keyboard input from `console` to `ptm`.
 //   - pumps pty output's  to `os.Stdout`.
-func startDataPump(ptm, logWritter, console *os.File) {
+func startDataPump(ptm, logWritter, console *os.File, fromStdin bool) {
 	// Pump A’s output (parent’s stdin) into the pipe → child
-	go func() {
-		io.Copy(logWritter, os.Stdin)
-		logWritter.Close()
-
-		// When A finishes the kernel resets
-		// tty to cooked. Force RAW again so
-		// keystrokes keep flowing.
-		if _, err := term.MakeRaw(int(console.Fd())); err != nil {
-			log.Fatalf("unable to turn console raw - %v", err)
-		}
-	}()
+	if fromStdin {
+		go func() {
+			io.Copy(logWritter, os.Stdin)
+			logWritter.Close()
+
+			// When A finishes the kernel resets
+			// tty to cooked. Force RAW again so
+			// keystrokes keep flowing.
+			if _, err := term.MakeRaw(int(console.Fd())); err != nil {
+				log.Fatalf("unable to turn console raw - %v", err)
+			}
+		}()
+	}
 
-	// Pump keyboard → PTY master
+	// Pump keyboard → PTY master. Mouse
+	// reports are escape sequences read
+	// from the tty as well, the raw tty
+	// passes them on byte for byte.
 	go io.Copy(ptm, console)
 
 	// Pump child screen → stdout
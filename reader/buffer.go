@@ -1,14 +1,21 @@
 package reader
 
-import "strings"
+import (
+	"strings"
+	"sync"
+)
 
 type Buffer interface {
 	Stringify(string) string
+	Snapshot() []string
 	Resize(int)
 	Push(string)
 }
 
+// buffer - a ring of strings, safe for concurrent use: pushes and resizes
+// are serialized while reads share a copy of the items.
 type buffer struct {
+	mu     sync.RWMutex
 	buf    []string // buffer
 	size   int      // max size of the buffer
 	filled int      // currently filled size
@@ -29,6 +36,9 @@ func NewBuffer(size int) Buffer {
 // Push - takes a string and pushes into the buffer. If the buffer is full, it
 // replaces the oldest data with the newest data.
 func (b *buffer) Push(msg string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	b.buf[b.j] = msg
 	b.j = (b.j + 1) % b.size
 
@@ -43,6 +53,9 @@ func (b *buffer) Push(msg string) {
 
 // Resize - updates and size of the buffer and makes sure the old data is present
 func (b *buffer) Resize(newSize int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	if b.size > newSize {
 		b.shrink(newSize)
 	} else {
@@ -53,17 +66,21 @@ func (b *buffer) Resize(newSize int) {
 // Stringify - joins the data in the buffer into a single string with the
 // provided separator.
 func (b *buffer) Stringify(separator string) string {
-	if b.filled == 0 {
-		return ""
-	}
+	return strings.Join(b.Snapshot(), separator)
+}
+
+// Snapshot - returns a copy of the data in the buffer, oldest first. It is
+// not affected by later pushes or resizes.
+func (b *buffer) Snapshot() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 
 	final := make([]string, b.filled)
 	b.forEach(func(s string, i int) bool {
 		final[i] = s
 		return true
 	})
-
-	return strings.Join(final, separator)
+	return final
 }
 
 // ----------------------- PRIVATE
@@ -84,7 +101,7 @@ func (b *buffer) shrink(newSize int) {
 		return true
 	})
 
-	copy(nb.(*buffer), b)
+	copyBuffer(nb.(*buffer), b)
 }
 
 // enlarge - increases the size of the buffer and keeps old data
@@ -100,7 +117,7 @@ func (b *buffer) enlarge(newSize int) {
 		return true
 	})
 
-	copy(nb.(*buffer), b)
+	copyBuffer(nb.(*buffer), b)
 }
 
 // forEach - Iterates through each item in the buffer and calls the passed
@@ -122,8 +139,8 @@ func (b *buffer) forEach(act func(string, int) bool) {
 	}
 }
 
-// copy - copies one buffer items to another
-func copy(from, to *buffer) {
+// copyBuffer - copies one buffer items to another
+func copyBuffer(from, to *buffer) {
 	to.buf = from.buf
 	to.size = from.size
 	to.filled = from.filled
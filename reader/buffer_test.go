@@ -1,6 +1,11 @@
 package reader
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
 
 func Equal[T comparable](t *testing.T, expected, actual T) {
 	if expected == actual {
@@ -151,6 +156,65 @@ func Test_BufferResizeTableDriven(t *testing.T) {
 	}
 }
 
+func Test_BufferSnapshot(t *testing.T) {
+	buf := NewBuffer(2)
+	buf.Push("a")
+	buf.Push("b")
+
+	snapshot := buf.Snapshot()
+	buf.Push("c")
+	buf.Resize(3)
+
+	Equal(t, "a|b", strings.Join(snapshot, "|"))
+	Equal(t, "b|c", strings.Join(buf.Snapshot(), "|"))
+}
+
+func Test_BufferConcurrent(t *testing.T) {
+	const producers, pushes = 4, 1000
+
+	buf := NewBuffer(8)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < pushes; i += 1 {
+				buf.Push(fmt.Sprintf("%d %d", p, i))
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// the lines of every producer stay in order whatever the resizes
+	for size := 1; ; size = size%16 + 1 {
+		select {
+		case <-done:
+			Equal(t, true, len(buf.Snapshot()) <= 16)
+			return
+		default:
+		}
+
+		buf.Resize(size)
+		last := map[string]int{}
+		for _, item := range buf.Snapshot() {
+			var producer string
+			var i int
+			fmt.Sscanf(item, "%s %d", &producer, &i)
+			if previous, ok := last[producer]; ok && previous >= i {
+				t.Fatalf("line %d of producer %s after line %d", i, producer, previous)
+			}
+			last[producer] = i
+		}
+		_ = buf.Stringify("\n")
+	}
+}
+
 func Benchmark_Buffer(b *testing.B) {
 	buf := NewBuffer(4)
 
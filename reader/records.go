@@ -0,0 +1,105 @@
+package reader
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/SpandanBG/logctrl/utils"
+)
+
+var (
+	javaFrame     = regexp.MustCompile(`^(at |\.\.\. \d+ more|Caused by:|Suppressed:)`)
+	javaException = regexp.MustCompile(`^([a-z]\w*\.)+[A-Z]\w*(Exception|Error)(: .*)?$`)
+	goroutineHead = regexp.MustCompile(`^goroutine \d+ \[.*\]:$`)
+	goFrame       = regexp.MustCompile(`^([\w./*()\[\]{}-]+\(.*\)|created by .+)$`)
+	pythonChained = regexp.MustCompile(`^(During handling of the above exception|The above exception was the direct cause)`)
+)
+
+const pythonTraceback = "Traceback (most recent call last):"
+
+// run - consecutive records whose first lines are the same once numbers are
+// normalized, like the lines of a retry loop.
+type run struct {
+	start int       // first line of the first record
+	count int       // records in the run
+	last  time.Time // when the last record was appended, zero if unknown
+}
+
+// runKey - returns the plain `line` with its numbers normalized, equal for
+// the lines of a run.
+func runKey(line string) string {
+	var key strings.Builder
+	key.Grow(len(line))
+
+	number := false
+	for i := 0; i < len(line); i += 1 {
+		digit := line[i] >= '0' && line[i] <= '9'
+		switch {
+		case !digit:
+			key.WriteByte(line[i])
+		case !number:
+			key.WriteByte('#')
+		}
+		number = digit
+	}
+	return key.String()
+}
+
+// Records - groups lines into records, and records into runs of repeats, as
+// the lines are read. The lines appended to a session are grouped so.
+type Records struct {
+	grouper grouper
+	started bool   // if `true` a record was started
+	key     string // run key of the first line of the last record
+}
+
+// Add - adds the next `line`. Returns if it starts a new record, and if that
+// record repeats the one before it.
+func (r *Records) Add(line string) (bool, bool) {
+	if r.grouper.continues(line) && r.started {
+		return false, false
+	}
+
+	key := runKey(utils.StripANSI(utils.ApplyCarriageReturns(line)))
+	repeats := r.started && key == r.key
+	r.started, r.key = true, key
+	return true, repeats
+}
+
+// grouper - decides which lines continue the record started by an earlier
+// line, such as the frames of a stack trace.
+type grouper struct {
+	goroutine bool // within the frames of a Go goroutine dump
+	traceback bool // within the frames of a Python traceback
+}
+
+// continues - reports if the `line` belongs to the record of the lines before
+// it. Lines are to be given in order.
+func (g *grouper) continues(line string) bool {
+	line = utils.StripANSI(line)
+	indented := len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+	trimmed := strings.TrimSpace(line)
+
+	switch {
+	case len(trimmed) == 0, indented:
+		return true
+	case strings.HasPrefix(trimmed, pythonTraceback), pythonChained.MatchString(trimmed):
+		g.traceback = true
+		return true
+	case g.traceback:
+		// the exception closing the traceback
+		g.traceback = false
+		return true
+	case goroutineHead.MatchString(trimmed):
+		g.goroutine = true
+		return true
+	case g.goroutine && goFrame.MatchString(trimmed):
+		return true
+	case javaFrame.MatchString(trimmed), javaException.MatchString(trimmed):
+		return true
+	}
+
+	g.goroutine = false
+	return false
+}
@@ -0,0 +1,207 @@
+package reader
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_RunKeyTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		line     string
+		expected string
+	}{
+		{"retry 12 in 400ms", "retry # in #ms"},
+		{"10.0.0.1:8080", "#.#.#.#:#"},
+		{"no numbers", "no numbers"},
+		{"", ""},
+		{"٣ non ascii digits 7", "٣ non ascii digits #"},
+	} {
+		t.Run(test.line, func(t *testing.T) {
+			Equal(t, test.expected, runKey(test.line))
+		})
+	}
+}
+
+func Test_GroupRecordsTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		lines []string
+		marks string // `s` for a line starting a record, `c` for a continuation
+	}{
+		{
+			name:  "plain lines",
+			lines: []string{"a", "b", "c"},
+			marks: "sss",
+		},
+		{
+			name: "java",
+			lines: []string{
+				"ERROR failed",
+				"java.lang.IllegalStateException: boom",
+				"\tat com.example.App.run(App.java:10)",
+				`Exception in thread "main" java.lang.IllegalStateException: boom`,
+				"\tat com.example.App.run(App.java:10)",
+				"Caused by: java.io.IOException: closed",
+				"\tat com.example.Io.read(Io.java:3)",
+				"\t... 4 more",
+				"INFO next",
+			},
+			marks: "sccsccccs",
+		},
+		{
+			name: "go panic",
+			lines: []string{
+				"panic: boom",
+				"",
+				"goroutine 1 [running]:",
+				"main.main()",
+				"\t/src/main.go:5 +0x1d",
+				"created by main.start in goroutine 1",
+				"exit status 2",
+				"main.main()",
+			},
+			marks: "scccccss",
+		},
+		{
+			name: "python",
+			lines: []string{
+				"ERROR handler failed",
+				"Traceback (most recent call last):",
+				`  File "app.py", line 3, in <module>`,
+				"    main()",
+				"ValueError: bad value",
+				"INFO next",
+			},
+			marks: "sccccs",
+		},
+		{
+			name: "colored frames",
+			lines: []string{
+				"\x1b[31mERROR\x1b[0m failed",
+				"\x1b[2m    at handler (index.js:3:9)\x1b[0m",
+			},
+			marks: "sc",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var g grouper
+			var actual strings.Builder
+			for _, line := range test.lines {
+				if g.continues(line) {
+					actual.WriteByte('c')
+				} else {
+					actual.WriteByte('s')
+				}
+			}
+
+			Equal(t, test.marks, actual.String())
+		})
+	}
+}
+
+func Test_RecordsTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		lines []string
+		marks string // `s` for a line starting a record, `r` for one repeating the previous record, `c` for a continuation
+	}{
+		{"plain lines", []string{"a", "b", "a"}, "sss"},
+		{"numbers differ", []string{"retry 1", "retry 2", "\x1b[33mretry 3\x1b[0m", "done"}, "srrs"},
+		{"repeated records", []string{"ERROR x", "\tat a", "ERROR x", "\tat b"}, "scrc"},
+		{"first line indented", []string{"  a", "  a"}, "sc"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var records Records
+			var actual strings.Builder
+			for _, line := range test.lines {
+				switch starts, repeats := records.Add(line); {
+				case repeats:
+					actual.WriteByte('r')
+				case starts:
+					actual.WriteByte('s')
+				default:
+					actual.WriteByte('c')
+				}
+			}
+
+			Equal(t, test.marks, actual.String())
+		})
+	}
+}
+
+func Test_SessionRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+
+	s, err := NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"start", "ERROR x", "\tat a", "\tat b", "next"} {
+		s.Append(line)
+	}
+	s.Close()
+
+	// records are rebuilt when the session is continued
+	reopened, err := NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	for _, test := range []struct {
+		line     int
+		from, to int
+	}{
+		{0, 0, 1},
+		{1, 1, 4},
+		{3, 1, 4},
+		{4, 4, 5},
+	} {
+		from, to := reopened.Record(test.line)
+		Equal(t, test.from, from)
+		Equal(t, test.to, to)
+	}
+}
+
+func Test_SessionRunsTableDriven(t *testing.T) {
+	s, err := NewSession(filepath.Join(t.TempDir(), "session.log"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, line := range []string{
+		"start",
+		"retry 1 in 200ms",
+		"retry 2 in 400ms",
+		"\x1b[33mretry 3 in 800ms\x1b[0m",
+		"ERROR x",
+		"\tat a",
+		"ERROR x",
+		"\tat a",
+		"done",
+	} {
+		s.Append(line)
+	}
+
+	for _, test := range []struct {
+		name            string
+		line            int
+		from, to, count int
+	}{
+		{"single line", 0, 0, 1, 1},
+		{"numbers normalized", 1, 1, 4, 3},
+		{"colors dropped", 3, 1, 4, 3},
+		{"records repeated", 7, 4, 8, 2},
+		{"after the runs", 8, 8, 9, 1},
+		{"out of range", 9, 9, 9, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			from, to, count, _ := s.Run(test.line)
+			Equal(t, test.from, from)
+			Equal(t, test.to, to)
+			Equal(t, test.count, count)
+		})
+	}
+}
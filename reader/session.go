@@ -0,0 +1,473 @@
+package reader
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SpandanBG/logctrl/utils"
+)
+
+const (
+	bookmarksFileSuffix = ".bookmarks.json"
+	flushSize           = 64 * 1024 // bytes of lines kept in memory before being written
+)
+
+type Session interface {
+	Append(string)
+	Len() int
+	Line(int) string
+	Lines(int, int) []string
+	Path() string
+	ToggleBookmark(int, string) (bool, error)
+	SetBookmarkNote(int, string) error
+	Bookmarks() []Bookmark
+	NextBookmark(int, bool) (Bookmark, bool)
+	Record(int) (int, int)
+	Run(int) (int, int, int, time.Time)
+	Time(int) time.Time
+	Templates() []Template
+	MatchTemplate(string) int
+	Template(int) int
+	Close()
+}
+
+// Bookmark - a marked line of the session with an optional note.
+type Bookmark struct {
+	Line int    `json:"line"`
+	Note string `json:"note,omitempty"`
+}
+
+type session struct {
+	mu        sync.RWMutex
+	appending sync.Mutex // held by Append, its lines are grouped outside `mu`
+
+	file      *os.File
+	offsets   []int64     // start offset of each line within `file`
+	size      int64       // number of bytes of the lines, written or pending
+	written   int64       // number of bytes written to `file`
+	pending   []byte      // lines appended after `written`, not written yet
+	resumed   int         // lines read from a previous session
+	times     []time.Time // when each line after the resumed ones was appended
+	bookmarks []Bookmark  // sorted by line
+	starts    []int       // first line of each record, sorted
+	kinds     []int       // template ID of each record, as `starts`
+	records   Records     // groups the lines appended into records
+	runs      []run       // repeated records, sorted by start
+	templates templates   // templates of the first lines of the records
+}
+
+// NewSession - opens the session file at `path`, creating it if required. If
+// the file already holds lines from a previous run those are indexed and its
+// bookmarks are loaded, so that the session can be continued. An empty `path`
+// creates a new session file within `dir`, or the temp dir if `dir` is empty.
+func NewSession(path, dir string) (Session, error) {
+	var (
+		file *os.File
+		err  error
+	)
+
+	if len(dir) == 0 {
+		dir = logFileLocation
+	}
+
+	if len(path) == 0 {
+		file, err = os.CreateTemp(dir, logFileName)
+	} else {
+		file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{file: file}
+
+	if err := s.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if err := s.loadBookmarks(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Append - adds the line at the end of the session and indexes it. The lines
+// are written to the session file in chunks, those pending being read from
+// memory. The line is grouped into its record and template before the
+// readers are blocked, only to store the result.
+func (s *session) Append(line string) {
+	s.appending.Lock()
+	defer s.appending.Unlock()
+
+	now := time.Now()
+	g := s.group(line)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index(g, now)
+	s.offsets = append(s.offsets, s.size)
+	s.times = append(s.times, now)
+	s.pending = append(append(s.pending, line...), '\n')
+	s.size += int64(len(line) + 1)
+
+	if len(s.pending) >= flushSize {
+		s.flush()
+	}
+}
+
+// Len - returns the number of lines in the session.
+func (s *session) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.offsets)
+}
+
+// Line - returns the line at index `i` of the session. Returns an empty string
+// if `i` is out of range.
+func (s *session) Line(i int) string {
+	lines := s.Lines(i, i+1)
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[0]
+}
+
+// Lines - returns the lines within [from, to) of the session. The range is
+// clamped to the lines available.
+func (s *session) Lines(from, to int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	from = max(from, 0)
+	to = min(to, len(s.offsets))
+	if from >= to {
+		return nil
+	}
+
+	end := s.size
+	if to < len(s.offsets) {
+		end = s.offsets[to]
+	}
+
+	raw := make([]byte, end-s.offsets[from])
+	written := max(min(end, s.written)-s.offsets[from], 0)
+	if _, err := s.file.ReadAt(raw[:written], s.offsets[from]); err != nil && err != io.EOF {
+		return nil
+	}
+	copy(raw[written:], s.pending[max(s.offsets[from]-s.written, 0):])
+
+	lines := make([]string, to-from)
+	for i := range lines {
+		start := s.offsets[from+i] - s.offsets[from]
+		stop := int64(len(raw))
+		if from+i+1 < to {
+			stop = s.offsets[from+i+1] - s.offsets[from]
+		}
+		lines[i] = strings.TrimSuffix(string(raw[start:stop]), "\n")
+	}
+
+	return lines
+}
+
+// Record - returns the range [from, to) of the lines in the record holding
+// the `line`. A record is a line with the lines continuing it, like the frames
+// of a stack trace.
+func (s *session) Record(line int) (int, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if line < 0 || line >= len(s.offsets) {
+		return line, line
+	}
+
+	i := sort.SearchInts(s.starts, line+1) - 1
+	to := len(s.offsets)
+	if i+1 < len(s.starts) {
+		to = s.starts[i+1]
+	}
+	return s.starts[i], to
+}
+
+// Run - returns the range [from, to) of the lines in the run of repeated
+// records holding the `line`, the number of records in it and when the last
+// one was appended, zero if it was read from a previous session.
+func (s *session) Run(line int) (int, int, int, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if line < 0 || line >= len(s.offsets) {
+		return line, line, 0, time.Time{}
+	}
+
+	i := sort.Search(len(s.runs), func(i int) bool { return s.runs[i].start > line }) - 1
+	to := len(s.offsets)
+	if i+1 < len(s.runs) {
+		to = s.runs[i+1].start
+	}
+	return s.runs[i].start, to, s.runs[i].count, s.runs[i].last
+}
+
+// Time - returns when the `line` was appended, zero if it was read from a
+// previous session or is out of range.
+func (s *session) Time(line int) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if line < s.resumed || line >= len(s.offsets) {
+		return time.Time{}
+	}
+	return s.times[line-s.resumed]
+}
+
+// Templates - returns the templates of the first lines of the records, by
+// ID.
+func (s *session) Templates() []Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.templates.list)
+}
+
+// MatchTemplate - returns the ID of the template of the plain `line`, -1 if
+// none.
+func (s *session) MatchTemplate(line string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.templates.match(line)
+}
+
+// Template - returns the ID of the template of the record holding the `line`,
+// as found when the record was appended. Returns -1 if `line` is out of range.
+func (s *session) Template(line int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if line < 0 || line >= len(s.offsets) {
+		return -1
+	}
+	return s.kinds[sort.SearchInts(s.starts, line+1)-1]
+}
+
+// Path - returns the location of the session file.
+func (s *session) Path() string {
+	return s.file.Name()
+}
+
+// ToggleBookmark - bookmarks the `line` with the `note`, or removes the
+// bookmark if the line was already bookmarked. Returns `true` if the line is
+// bookmarked after the call, and the error saving the bookmarks if any.
+func (s *session) ToggleBookmark(line int, note string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, found := s.findBookmark(line)
+	if found {
+		s.bookmarks = slices.Delete(s.bookmarks, i, i+1)
+	} else {
+		s.insertBookmark(i, Bookmark{Line: line, Note: note})
+	}
+
+	return !found, s.saveBookmarks()
+}
+
+// SetBookmarkNote - updates the note of the bookmark on `line`, bookmarking
+// the line if required. Returns the error saving the bookmarks if any.
+func (s *session) SetBookmarkNote(line int, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, found := s.findBookmark(line); found {
+		s.bookmarks[i].Note = note
+	} else {
+		s.insertBookmark(i, Bookmark{Line: line, Note: note})
+	}
+
+	return s.saveBookmarks()
+}
+
+// Bookmarks - returns a copy of all the bookmarks sorted by line.
+func (s *session) Bookmarks() []Bookmark {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Bookmark(nil), s.bookmarks...)
+}
+
+// NextBookmark - returns the closest bookmark after `line`, or before it if
+// `backward` is set. Returns `false` if there is no such bookmark.
+func (s *session) NextBookmark(line int, backward bool) (Bookmark, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, found := s.findBookmark(line)
+	if backward {
+		i -= 1
+	} else if found {
+		i += 1
+	}
+
+	if i < 0 || i >= len(s.bookmarks) {
+		return Bookmark{}, false
+	}
+	return s.bookmarks[i], true
+}
+
+// Close - writes the pending lines and closes the session file.
+func (s *session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flush()
+	s.file.Close()
+}
+
+// ----------------------- PRIVATE
+
+// load - records the offset of every line already present in the file.
+func (s *session) load() error {
+	r := bufio.NewReader(s.file)
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			s.index(s.group(strings.TrimSuffix(line, "\n")), time.Time{})
+			s.offsets = append(s.offsets, s.size)
+			s.size += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	s.resumed = len(s.offsets)
+
+	// terminate a trailing partial line so new lines don't join it
+	if s.size > 0 {
+		last := make([]byte, 1)
+		if _, err := s.file.ReadAt(last, s.size-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			n, err := s.file.WriteAt([]byte("\n"), s.size)
+			if err != nil {
+				return err
+			}
+			s.size += int64(n)
+		}
+	}
+
+	s.written = s.size
+	return nil
+}
+
+// grouped - how a line is grouped: if it starts a new record, if that
+// record repeats the previous one, and the tokens of its template.
+type grouped struct {
+	starts  bool
+	repeats bool
+	tokens  []string
+}
+
+// group - returns how the `line` about to be indexed is grouped, the costly
+// part of indexing it, done without holding `mu`.
+func (s *session) group(line string) grouped {
+	var g grouped
+	if g.starts, g.repeats = s.records.Add(line); g.starts {
+		g.tokens = tokenize(utils.StripANSI(utils.ApplyCarriageReturns(line)))
+	}
+	return g
+}
+
+// index - starts a new record with the line about to be indexed as grouped
+// by `g`, unless it continues the previous one. A new record is added to its
+// template and joins the run of the previous one if it repeats it, `seen`
+// being when it was appended.
+func (s *session) index(g grouped, seen time.Time) {
+	if !g.starts {
+		return
+	}
+
+	start := len(s.offsets)
+	s.starts = append(s.starts, start)
+	s.kinds = append(s.kinds, s.templates.add(g.tokens, start, seen))
+
+	if n := len(s.runs); n > 0 && g.repeats {
+		s.runs[n-1].count += 1
+		s.runs[n-1].last = seen
+		return
+	}
+
+	s.runs = append(s.runs, run{start: start, count: 1, last: seen})
+}
+
+// flush - writes the pending lines at the end of the session file. What
+// could not be written is kept pending, still read from memory.
+func (s *session) flush() {
+	n, _ := s.file.WriteAt(s.pending, s.written)
+	s.written += int64(n)
+	s.pending = s.pending[:copy(s.pending, s.pending[n:])]
+}
+
+// findBookmark - returns the index of the bookmark on `line`, or the index at
+// which it would be inserted, and whether it was found.
+func (s *session) findBookmark(line int) (int, bool) {
+	i := sort.Search(len(s.bookmarks), func(i int) bool {
+		return s.bookmarks[i].Line >= line
+	})
+	return i, i < len(s.bookmarks) && s.bookmarks[i].Line == line
+}
+
+// insertBookmark - inserts the bookmark at index `i` keeping the order.
+func (s *session) insertBookmark(i int, b Bookmark) {
+	s.bookmarks = slices.Insert(s.bookmarks, i, b)
+}
+
+func (s *session) bookmarksPath() string {
+	return s.file.Name() + bookmarksFileSuffix
+}
+
+// loadBookmarks - reads the bookmarks file of the session, if any.
+func (s *session) loadBookmarks() error {
+	raw, err := os.ReadFile(s.bookmarksPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw, &s.bookmarks); err != nil {
+		return err
+	}
+
+	sort.Slice(s.bookmarks, func(i, j int) bool {
+		return s.bookmarks[i].Line < s.bookmarks[j].Line
+	})
+	return nil
+}
+
+// saveBookmarks - writes the bookmarks next to the session file. The
+// bookmarks are still usable for the current run if they fail to be saved.
+func (s *session) saveBookmarks() error {
+	raw, err := json.MarshalIndent(s.bookmarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.bookmarksPath(), raw, 0644)
+}
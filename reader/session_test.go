@@ -0,0 +1,236 @@
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_SessionLines(t *testing.T) {
+	session, err := NewSession(filepath.Join(t.TempDir(), "session.log"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	for _, each := range []string{"a", "", "ccc", "d"} {
+		session.Append(each)
+	}
+
+	Equal(t, 4, session.Len())
+	Equal(t, "ccc", session.Line(2))
+	Equal(t, "", session.Line(4))
+	Equal(t, "a||ccc", strings.Join(session.Lines(-1, 3), "|"))
+	Equal(t, "ccc|d", strings.Join(session.Lines(2, 10), "|"))
+}
+
+func Test_SessionBookmarksTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		toggle   []int
+		from     int
+		backward bool
+		expected int
+		found    bool
+	}{
+		{
+			name:   "no bookmarks",
+			toggle: []int{},
+			from:   0,
+		},
+		{
+			name:     "next from a bookmark",
+			toggle:   []int{1, 5, 3},
+			from:     3,
+			expected: 5,
+			found:    true,
+		},
+		{
+			name:     "previous from between bookmarks",
+			toggle:   []int{1, 5, 3},
+			from:     4,
+			backward: true,
+			expected: 3,
+			found:    true,
+		},
+		{
+			name:   "next past the last bookmark",
+			toggle: []int{1, 5},
+			from:   5,
+		},
+		{
+			name:     "toggled off bookmark is skipped",
+			toggle:   []int{1, 3, 5, 3},
+			from:     1,
+			expected: 5,
+			found:    true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			session, err := NewSession(filepath.Join(t.TempDir(), "session.log"), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer session.Close()
+
+			for _, line := range test.toggle {
+				session.ToggleBookmark(line, "")
+			}
+
+			bookmark, found := session.NextBookmark(test.from, test.backward)
+			Equal(t, test.found, found)
+			Equal(t, test.expected, bookmark.Line)
+		})
+	}
+}
+
+func Test_SessionBookmarksSaveError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+
+	session, err := NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	session.Append("a")
+
+	// a directory in place of the bookmarks file cannot be written
+	if err := os.Mkdir(path+bookmarksFileSuffix, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarked, err := session.ToggleBookmark(0, "")
+	Equal(t, true, bookmarked)
+	Equal(t, true, err != nil)
+	Equal(t, true, session.SetBookmarkNote(0, "kept") != nil)
+
+	// the bookmarks are kept for the current run
+	bookmarks := session.Bookmarks()
+	Equal(t, 1, len(bookmarks))
+	Equal(t, "kept", bookmarks[0].Note)
+}
+
+func Test_SessionReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+
+	session, err := NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Append("a")
+	session.Append("b")
+	session.ToggleBookmark(1, "")
+	session.SetBookmarkNote(1, "interesting")
+	session.Close()
+
+	session, err = NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	session.Append("c")
+
+	Equal(t, "a|b|c", strings.Join(session.Lines(0, 3), "|"))
+
+	bookmarks := session.Bookmarks()
+	Equal(t, 1, len(bookmarks))
+	Equal(t, 1, bookmarks[0].Line)
+	Equal(t, "interesting", bookmarks[0].Note)
+
+	// only the lines appended since reopening have a time
+	Equal(t, true, session.Time(1).IsZero())
+	Equal(t, false, session.Time(2).IsZero())
+	Equal(t, true, session.Time(3).IsZero())
+}
+
+func Test_SessionConcurrent(t *testing.T) {
+	session, err := NewSession(filepath.Join(t.TempDir(), "session.log"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i += 1 {
+			session.Append(fmt.Sprintf("ERROR request %c failed", 'a'+i/10%26))
+			session.Append("\tat a.b(App.java:1)")
+		}
+	}()
+
+	// every read sees a consistent session while the lines are appended
+	for i := 0; i < 4; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 2000; n = session.Len() {
+				lines := session.Lines(n-4, n)
+				if len(lines) > 0 && len(lines) != min(n, 4) {
+					t.Errorf("expected %d lines, got %d", min(n, 4), len(lines))
+				}
+				start, end := session.Record(n - 1)
+				Equal(t, true, n == 0 || start < end)
+				session.Run(n - 1)
+				session.Templates()
+				session.ToggleBookmark(n/2, "")
+				session.Bookmarks()
+			}
+		}()
+	}
+	wg.Wait()
+
+	Equal(t, 2000, session.Len())
+	first, last := session.Record(1999)
+	Equal(t, 1998, first)
+	Equal(t, 2000, last)
+	_, _, count, _ := session.Run(1999)
+	Equal(t, 10, count)
+}
+
+func Test_SessionPendingLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+	session, err := NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%04d %s", i, strings.Repeat("x", 995))
+		session.Append(lines[i])
+	}
+
+	// lines are read the same whether written or pending
+	Equal(t, strings.Join(lines, "|"), strings.Join(session.Lines(0, 100), "|"))
+	Equal(t, lines[70], session.Line(70))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Equal(t, true, info.Size() > 0 && info.Size() < 100*1001)
+
+	session.Close()
+	if info, err = os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	Equal(t, int64(100*1001), info.Size())
+}
+
+func Benchmark_SessionAppend(b *testing.B) {
+	session, err := NewSession(filepath.Join(b.TempDir(), "session.log"), "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer session.Close()
+
+	for i := 0; i < b.N; i += 1 {
+		session.Append(fmt.Sprintf("2024-05-01T10:00:%02d INFO request %d served in %dms", i%60, i, i%500))
+	}
+}
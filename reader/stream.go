@@ -2,9 +2,17 @@ package reader
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/SpandanBG/logctrl/utils"
 )
 
 const (
@@ -13,59 +21,119 @@ const (
 )
 
 type Stream interface {
-	Start(chan bool)
+	Start(context.Context) <-chan int
 	SetBufferSize(int)
 	GetLive() string
+	Snapshot() []string
+	Session() Session
+	Err() error
+	ExitCode() (int, bool)
 	Close()
 }
 
 type stream struct {
-	// log files
-	logFile *os.File
-	logFeed *os.File
+	// log feed, the status of its producer and the session it is recorded
+	// into
+	session      Session
+	logFeed      *os.File
+	status       *os.File // holds the exit code of the producer, nil if unknown
+	keepProgress bool     // if `true` lines are recorded with their `\r` states
 
 	// access buffers
 	liveAccessBuffer   Buffer
 	randomAccessBuffer Buffer
 
-	// notification channel
-	next chan bool
+	// notifications of the lines read, coalesced
+	next chan int
+
+	// stops the reading of the log feed, done once it is stopped
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// how the reading of the log feed ended
+	endMu     sync.Mutex
+	err       error // error that stopped the reading, if any
+	exitCode  int   // exit code of the producer
+	exitKnown bool  // if `true` the exit code of the producer is known
 }
 
-// NewStream - Creates a new stream object from the `logFeed` provided.
-func NewStream(logFeed *os.File) Stream {
-	logFile, err := os.CreateTemp(logFileLocation, logFileName)
+// NewStream - Creates a new stream object from the `logFeed` provided. The
+// logs are recorded into the session file at `sessionPath`, or into a new
+// file within `sessionDir` if it is empty. Lines overwritten with `\r` are
+// recorded as they end up on a terminal unless `keepProgress` is set. The
+// exit code of the producer of the feed is read from `status` once the feed
+// ends, if not nil.
+func NewStream(logFeed, status *os.File, sessionPath, sessionDir string, keepProgress bool) Stream {
+	session, err := NewSession(sessionPath, sessionDir)
 	if err != nil {
-		log.Fatalf("unable to create temp log file - %v", err)
+		log.Fatalf("unable to open session log file - %v", err)
 	}
 
 	return &stream{
-		logFile: logFile,
-		logFeed: logFeed,
+		session:            session,
+		logFeed:            logFeed,
+		status:             status,
+		keepProgress:       keepProgress,
+		liveAccessBuffer:   NewBuffer(1),
+		randomAccessBuffer: NewBuffer(1),
 	}
 }
 
-// Start - starts the stream. Takes `next` boolean channel which would be
-// notified when new logs has been fed into the stream from the producer.
-func (s *stream) Start(next chan bool) {
-	s.next = next
+// Start - starts reading the log feed in the background until its end or
+// the cancellation of `ctx`. Returns the channel notified with the number of
+// lines read so far, closed once the reading ends and the exit code of the
+// producer is known. The lines are recorded as fast as they come whatever the
+// pace of the receiver, a notification not yet received is replaced by the
+// newer one.
+func (s *stream) Start(ctx context.Context) <-chan int {
+	s.next = make(chan int, 1)
+	s.done = make(chan struct{})
+	ctx, s.cancel = context.WithCancel(ctx)
 
 	go func() {
-		teeReader := io.TeeReader(s.logFeed, s.logFile)
-		liveBuffer := bufio.NewScanner(teeReader)
-		for liveBuffer.Scan() {
-			s.next <- true
-			s.liveAccessBuffer.Push(liveBuffer.Text())
+		defer close(s.done)
+		defer close(s.next)
+
+		// closing the feed unblocks the reading
+		stop := context.AfterFunc(ctx, s.closeFeed)
+		defer stop()
+
+		err := ReadLines(s.logFeed, func(line string) {
+			if !s.keepProgress {
+				line = utils.ApplyCarriageReturns(line)
+			}
+			s.session.Append(line)
+			s.liveAccessBuffer.Push(line)
+			s.notify()
+		})
+
+		if err != nil && !errors.Is(err, os.ErrClosed) {
+			s.endMu.Lock()
+			s.err = err
+			s.endMu.Unlock()
+			s.notify()
+		}
+
+		// once cancelled the status is closed, or its producer ignored
+		if s.status == nil || ctx.Err() != nil {
+			return
+		}
+		if code, ok := readExitCode(s.status); ok {
+			s.endMu.Lock()
+			s.exitCode, s.exitKnown = code, true
+			s.endMu.Unlock()
 		}
 	}()
+
+	return s.next
 }
 
-// SetBufferSize - sets the size of `randomAccessBuffer` and `liveAccessBuffer`.
-// This function would create a new buffer entirely and so all previous data
-// will be wiped clean.
+// SetBufferSize - sets the size of `randomAccessBuffer` and `liveAccessBuffer`,
+// at least 1. The newest data that fits is kept.
 func (s *stream) SetBufferSize(size int) {
-	s.randomAccessBuffer = NewBuffer(size)
-	s.liveAccessBuffer = NewBuffer(size)
+	size = max(size, 1)
+	s.randomAccessBuffer.Resize(size)
+	s.liveAccessBuffer.Resize(size)
 }
 
 // GetLive - returns the live logs that are currently being pushed
@@ -73,9 +141,106 @@ func (s *stream) GetLive() string {
 	return s.liveAccessBuffer.Stringify("\n")
 }
 
-// Close - closes all pipes and files
+// Snapshot - returns a copy of the live logs, oldest first, safe to keep
+// while new lines are pushed.
+func (s *stream) Snapshot() []string {
+	return s.liveAccessBuffer.Snapshot()
+}
+
+// Session - returns the session the logs are recorded into
+func (s *stream) Session() Session {
+	return s.session
+}
+
+// Err - returns the error that stopped the reading of the log feed, if any
+func (s *stream) Err() error {
+	s.endMu.Lock()
+	defer s.endMu.Unlock()
+	return s.err
+}
+
+// ExitCode - returns the exit code of the producer of the log feed, and if
+// it is known. It is only known once the feed is read.
+func (s *stream) ExitCode() (int, bool) {
+	s.endMu.Lock()
+	defer s.endMu.Unlock()
+	return s.exitCode, s.exitKnown
+}
+
+// Close - stops the reading of the log feed, waiting for it, and closes all
+// pipes and files
 func (s *stream) Close() {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+
+	s.closeFeed()
+	s.session.Close()
+}
+
+// OpenPipe - returns the pipe inherited as the file descriptor `fd`, non
+// blocking for its reading to be stopped by closing it.
+func OpenPipe(fd int, name string) (*os.File, error) {
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+// ----------------------- PRIVATE
+
+// closeFeed - closes the log feed and the status of its producer.
+func (s *stream) closeFeed() {
 	s.logFeed.Close()
-	s.logFile.Close()
-	close(s.next)
+	if s.status != nil {
+		s.status.Close()
+	}
+}
+
+// notify - replaces the notification not yet received, if any, with the
+// number of lines read so far. Never blocks, the reading goroutine being the
+// only sender.
+func (s *stream) notify() {
+	lines := s.session.Len()
+
+	select {
+	case <-s.next:
+	default:
+	}
+	s.next <- lines
+}
+
+// ReadLines - calls `line` for every line read from the `feed` until its end,
+// whatever the length of the line. The line ending, `\n` or `\r\n`, is
+// dropped. Returns the error that stopped the reading, nil at the end of the
+// feed.
+func ReadLines(feed io.Reader, line func(string)) error {
+	reader := bufio.NewReader(feed)
+	for {
+		text, err := reader.ReadString('\n')
+		if len(text) > 0 {
+			text = strings.TrimSuffix(text, "\n")
+			line(strings.TrimSuffix(text, "\r"))
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readExitCode - reads the exit code written in decimal to the `status` once
+// the producer exits. Returns `false` if `status` is closed without a code.
+func readExitCode(status io.Reader) (int, bool) {
+	text, err := io.ReadAll(status)
+	if err != nil {
+		return 0, false
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(string(text)))
+	return code, err == nil
 }
@@ -0,0 +1,298 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func Test_ReadLinesTableDriven(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+
+	for _, test := range []struct {
+		name     string
+		feed     string
+		expected []string
+	}{
+		{"lines", "a\nb\n", []string{"a", "b"}},
+		{"no trailing newline", "a\nb", []string{"a", "b"}},
+		{"empty lines", "\n\na\n", []string{"", "", "a"}},
+		{"crlf", "a\r\nb\r\n", []string{"a", "b"}},
+		{"longer than the scanner buffer", "a\n" + long + "\nb\n", []string{"a", long, "b"}},
+		{"empty feed", "", nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var lines []string
+			err := ReadLines(strings.NewReader(test.feed), func(line string) {
+				lines = append(lines, line)
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			Equal(t, len(test.expected), len(lines))
+			Equal(t, strings.Join(test.expected, "|"), strings.Join(lines, "|"))
+		})
+	}
+}
+
+func Test_ReadLinesError(t *testing.T) {
+	failure := errors.New("broken feed")
+	feed := io.MultiReader(strings.NewReader("a\nb"), iotest.ErrReader(failure))
+
+	var lines []string
+	err := ReadLines(feed, func(line string) { lines = append(lines, line) })
+
+	if !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+	Equal(t, "a|b", strings.Join(lines, "|"))
+}
+
+func Test_StreamNotificationsCoalesced(t *testing.T) {
+	feed, producer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStream(feed, nil, filepath.Join(t.TempDir(), "session.log"), "", false)
+	defer s.Close()
+
+	s.SetBufferSize(10)
+	next := s.Start(context.Background())
+
+	// nothing is received while the lines are read, the producer must not
+	// wait for the receiver
+	for i := 0; i < 1000; i += 1 {
+		fmt.Fprintf(producer, "line %d\n", i)
+	}
+	producer.Close()
+
+	for deadline := time.Now().Add(5 * time.Second); s.Session().Len() < 1000; {
+		if time.Now().After(deadline) {
+			t.Fatalf("read %d lines without a receiver", s.Session().Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var notified []int
+	for lines := range next {
+		notified = append(notified, lines)
+	}
+
+	// at most the notification pending when the last line was recorded and
+	// the one replacing it
+	Equal(t, true, len(notified) <= 2)
+	Equal(t, 1000, notified[len(notified)-1])
+	Equal(t, "line 999", s.Session().Line(999))
+}
+
+func Test_StreamConcurrentReads(t *testing.T) {
+	feed, producer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStream(feed, nil, filepath.Join(t.TempDir(), "session.log"), "", false)
+	defer s.Close()
+
+	next := s.Start(context.Background())
+	go func() {
+		for i := 0; i < 1000; i += 1 {
+			fmt.Fprintf(producer, "line %d\n", i)
+		}
+		producer.Close()
+	}()
+
+	// the buffers are read and replaced while the lines are pushed
+	for size := 1; ; size = size%10 + 1 {
+		if _, ok := <-next; !ok {
+			break
+		}
+
+		s.SetBufferSize(size)
+		Equal(t, true, len(s.Snapshot()) <= size)
+		_ = s.GetLive()
+	}
+
+	Equal(t, 1000, s.Session().Len())
+	Equal(t, nil, s.Err())
+}
+
+func Test_StreamSetBufferSizeKeepsLines(t *testing.T) {
+	feed, producer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStream(feed, nil, filepath.Join(t.TempDir(), "session.log"), "", false)
+	defer s.Close()
+
+	s.SetBufferSize(4)
+	next := s.Start(context.Background())
+	fmt.Fprint(producer, "a\nb\nc\nd\ne\n")
+	producer.Close()
+	for range next {
+	}
+
+	for _, test := range []struct {
+		size     int
+		expected string
+	}{
+		{6, "b\nc\nd\ne"},
+		{2, "d\ne"},
+		{-3, "e"}, // shorter than the panels
+		{4, "e"},
+	} {
+		s.SetBufferSize(test.size)
+		Equal(t, test.expected, s.GetLive())
+	}
+}
+
+func Test_StreamExitCodeTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		status string
+		code   int
+		known  bool
+	}{
+		{"exit code", "3\n", 3, true},
+		{"success", "0\n", 0, true},
+		{"closed without a code", "", 0, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			feed, producer, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, exit, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewStream(feed, status, filepath.Join(t.TempDir(), "session.log"), "", false)
+			defer s.Close()
+
+			next := s.Start(context.Background())
+			fmt.Fprint(producer, "a\nb\n")
+			producer.Close()
+			fmt.Fprint(exit, test.status)
+			exit.Close()
+
+			for range next {
+			}
+
+			code, known := s.ExitCode()
+			Equal(t, test.code, code)
+			Equal(t, test.known, known)
+			Equal(t, 2, s.Session().Len())
+		})
+	}
+}
+
+func Test_StreamStopsOnCancel(t *testing.T) {
+	feed, producer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+
+	s := NewStream(feed, nil, filepath.Join(t.TempDir(), "session.log"), "", false)
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	next := s.Start(ctx)
+	fmt.Fprint(producer, "a\n")
+	<-next
+
+	stopped := make(chan struct{})
+	go func() {
+		for range next {
+		}
+		close(stopped)
+	}()
+
+	// the producer is still running, the reading stops all the same
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reading not stopped")
+	}
+
+	Equal(t, nil, s.Err())
+	_, known := s.ExitCode()
+	Equal(t, false, known)
+}
+
+func Test_StreamCloseWhileReading(t *testing.T) {
+	feed, producer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+
+	s := NewStream(feed, nil, filepath.Join(t.TempDir(), "session.log"), "", false)
+	next := s.Start(context.Background())
+
+	go func() {
+		for i := 0; i < 1000; i += 1 {
+			if _, err := fmt.Fprintf(producer, "line %d\n", i); err != nil {
+				return
+			}
+		}
+	}()
+	<-next
+
+	s.Close()
+	for range next {
+	}
+	Equal(t, nil, s.Err())
+}
+
+func Test_StreamCloseWithLiveStatus(t *testing.T) {
+	pipe := func(name string) (*os.File, *os.File) {
+		fds := make([]int, 2)
+		if err := syscall.Pipe(fds); err != nil {
+			t.Fatal(err)
+		}
+		// inherited pipes are blocking until opened
+		r, err := OpenPipe(fds[0], name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r, os.NewFile(uintptr(fds[1]), name)
+	}
+	feed, producer := pipe("feed")
+	status, exit := pipe("status")
+	defer exit.Close()
+
+	s := NewStream(feed, status, filepath.Join(t.TempDir(), "session.log"), "", false)
+	next := s.Start(context.Background())
+	fmt.Fprint(producer, "a\n")
+	producer.Close()
+	<-next
+
+	// the feed ended, the producer never writes its exit code
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("close blocked on the status")
+	}
+
+	_, known := s.ExitCode()
+	Equal(t, false, known)
+}
@@ -0,0 +1,157 @@
+package reader
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wildcard        = "<*>"
+	templateSimilar = 0.5 // share of the tokens equal for a line to join a template
+)
+
+// variable - the parts of a token that vary between lines of a template:
+// UUIDs, IPs, hex IDs and numbers.
+var variable = regexp.MustCompile(
+	`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}` +
+		`|\d{1,3}(\.\d{1,3}){3}(:\d+)?` +
+		`|0[xX][0-9a-fA-F]+` +
+		`|\b[0-9a-fA-F]*\d[0-9a-fA-F]*\b` +
+		`|\d+`,
+)
+
+// Template - the shape shared by the first lines of records, their varying
+// parts masked with `<*>`.
+type Template struct {
+	ID        int
+	Text      string
+	Count     int       // records of the template
+	First     int       // first line of the first record
+	Last      int       // first line of the last record
+	FirstSeen time.Time // zero if read from a previous session
+	LastSeen  time.Time // zero if read from a previous session
+}
+
+// templates - clusters lines into templates the way Drain does: lines are
+// split into tokens, grouped by their number of tokens and first token without
+// wildcard, and join the most similar template of their group, the tokens that
+// differ becoming wildcards.
+type templates struct {
+	list   []Template
+	tokens [][]string       // tokens of each template, by ID
+	groups map[string][]int // template IDs by number of tokens and first token without wildcard
+}
+
+// add - adds the `tokens` of the line starting a record at `at` to its
+// template, creating it if required, and returns the template ID. The line is
+// tokenized by the caller, the costly part being done outside any lock.
+func (t *templates) add(tokens []string, at int, seen time.Time) int {
+	key := groupKey(tokens)
+
+	id := t.find(key, tokens)
+	if id < 0 {
+		if t.groups == nil {
+			t.groups = map[string][]int{}
+		}
+
+		id = len(t.list)
+		t.groups[key] = append(t.groups[key], id)
+		t.tokens = append(t.tokens, tokens)
+		t.list = append(t.list, Template{
+			ID:        id,
+			Text:      strings.Join(tokens, " "),
+			First:     at,
+			FirstSeen: seen,
+		})
+	} else if merged, changed := merge(t.tokens[id], tokens); changed {
+		t.tokens[id] = merged
+		t.list[id].Text = strings.Join(merged, " ")
+	}
+
+	t.list[id].Count += 1
+	t.list[id].Last = at
+	t.list[id].LastSeen = seen
+	return id
+}
+
+// match - returns the ID of the template of the `line`, -1 if none.
+func (t *templates) match(line string) int {
+	tokens := tokenize(line)
+	return t.find(groupKey(tokens), tokens)
+}
+
+// find - returns the ID of the template of the group `key` most similar to
+// the `tokens`, -1 if none is similar enough.
+func (t *templates) find(key string, tokens []string) int {
+	best, bestScore := -1, templateSimilar
+	for _, id := range t.groups[key] {
+		if score := similarity(t.tokens[id], tokens); score >= bestScore && (best < 0 || score > bestScore) {
+			best, bestScore = id, score
+		}
+	}
+	return best
+}
+
+// tokenize - splits the plain `line` into tokens, masking their varying
+// parts.
+func tokenize(line string) []string {
+	tokens := strings.Fields(line)
+	for i, token := range tokens {
+		// every variable part holds a digit or, for UUIDs, a dash
+		if strings.ContainsAny(token, "0123456789-") {
+			tokens[i] = variable.ReplaceAllString(token, wildcard)
+		}
+	}
+	return tokens
+}
+
+// groupKey - returns the group of the template of the `tokens`: their number
+// and the first token without wildcard, with its position. Lines starting with
+// varying tokens, like timestamps, are spread over groups by the token after
+// them instead of all being compared with each other.
+func groupKey(tokens []string) string {
+	for i, token := range tokens {
+		if !strings.Contains(token, wildcard) {
+			return strconv.Itoa(len(tokens)) + " " + strconv.Itoa(i) + " " + token
+		}
+	}
+	return strconv.Itoa(len(tokens))
+}
+
+// similarity - returns the share of the `tokens` equal to those of the
+// `template` at the same position.
+func similarity(template, tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 1
+	}
+
+	equal := 0
+	for i, token := range tokens {
+		if template[i] == token {
+			equal += 1
+		}
+	}
+	return float64(equal) / float64(len(tokens))
+}
+
+// merge - returns the `template` with the tokens differing from the
+// `tokens` replaced by wildcards, and if any was.
+func merge(template, tokens []string) ([]string, bool) {
+	var merged []string
+	for i, token := range tokens {
+		if template[i] == token || template[i] == wildcard {
+			continue
+		}
+		if merged == nil {
+			merged = append([]string{}, template...)
+		}
+		merged[i] = wildcard
+	}
+
+	if merged == nil {
+		return template, false
+	}
+	return merged, true
+}
@@ -0,0 +1,160 @@
+package reader
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_TokenizeTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{"numbers", "took 200ms after 3 retries", "took <*>ms after <*> retries"},
+		{"uuid", "request 123e4567-e89b-12d3-a456-426614174000 done", "request <*> done"},
+		{"ip and port", "connected to 10.0.0.12:5432", "connected to <*>"},
+		{"hex ids", "commit 3fa9c0de at 0x7ffee4", "commit <*> at <*>"},
+		{"key values", "user=42 status=ok", "user=<*> status=ok"},
+		{"words kept", "cache miss for users", "cache miss for users"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			Equal(t, test.expected, strings.Join(tokenize(test.line), " "))
+		})
+	}
+}
+
+func Test_TemplatesTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		lines    []string
+		expected []string // text and count of each template, by ID
+	}{
+		{
+			name:     "varying numbers",
+			lines:    []string{"took 200ms", "took 31ms", "took 5ms"},
+			expected: []string{"took <*>ms 3"},
+		},
+		{
+			name: "varying words",
+			lines: []string{
+				"user alice logged in",
+				"user bob logged in",
+				"user carol logged out",
+			},
+			expected: []string{"user <*> logged <*> 3"},
+		},
+		{
+			name: "too different",
+			lines: []string{
+				"cache hit for users",
+				"cache miss on the orders",
+				"cache miss for orders",
+			},
+			expected: []string{"cache <*> for <*> 2", "cache miss on the orders 1"},
+		},
+		{
+			name:     "token counts apart",
+			lines:    []string{"GET /health", "GET /health 200"},
+			expected: []string{"GET /health 1", "GET /health <*> 1"},
+		},
+		{
+			name: "varying first token",
+			lines: []string{
+				"10:00:01 GET /users",
+				"10:00:02 POST /users",
+				"10:00:03 GET /orders",
+			},
+			expected: []string{"<*>:<*>:<*> GET <*> 2", "<*>:<*>:<*> POST /users 1"},
+		},
+		{
+			name:     "empty lines",
+			lines:    []string{"", "  "},
+			expected: []string{" 2"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var ts templates
+			for i, line := range test.lines {
+				ts.add(tokenize(line), i, time.Time{})
+			}
+
+			var actual []string
+			for _, template := range ts.list {
+				actual = append(actual, fmt.Sprintf("%s %d", template.Text, template.Count))
+			}
+			Equal(t, strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+
+			for _, line := range test.lines {
+				if ts.match(line) < 0 {
+					t.Errorf("expected %q to match its template", line)
+				}
+			}
+		})
+	}
+}
+
+func Test_GroupKeyTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		line     string
+		expected string
+	}{
+		{"GET /health 200", "3 0 GET"},
+		{"10:00:01 GET /health", "3 1 GET"},
+		{"42 17", "2"},
+		{"", "0"},
+	} {
+		t.Run(test.line, func(t *testing.T) {
+			Equal(t, test.expected, groupKey(tokenize(test.line)))
+		})
+	}
+}
+
+func Test_SessionTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+
+	s, err := NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"GET /users 200 12ms",
+		"\x1b[31mERROR\x1b[0m db timeout",
+		"\tat query (db.js:4:1)",
+		"GET /orders 200 3ms",
+		"ERROR db timeout",
+	} {
+		s.Append(line)
+	}
+	s.Close()
+
+	// templates are rebuilt when the session is continued
+	reopened, err := NewSession(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	list := reopened.Templates()
+	Equal(t, 2, len(list))
+	Equal(t, "GET <*> <*> <*>ms", list[0].Text)
+	Equal(t, 2, list[0].Count)
+	Equal(t, 0, list[0].First)
+	Equal(t, 3, list[0].Last)
+	Equal(t, "ERROR db timeout", list[1].Text)
+	Equal(t, 4, list[1].Last)
+	Equal(t, true, list[1].LastSeen.IsZero())
+
+	// the template of each record is the one it was added to
+	for line, expected := range []int{0, 1, 1, 0, 1} {
+		Equal(t, expected, reopened.Template(line))
+	}
+	Equal(t, -1, reopened.Template(5))
+
+	Equal(t, 0, reopened.MatchTemplate("GET /items 404 1ms"))
+	Equal(t, 1, reopened.MatchTemplate("ERROR db timeout"))
+	Equal(t, -1, reopened.MatchTemplate("at query (db.js:4:1)"))
+}
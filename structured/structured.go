@@ -0,0 +1,201 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Field - a named value of a structured log line.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Fields - the fields of a line, in the order they appear in it.
+type Fields []Field
+
+// Get - returns the value of the field `key`.
+func (f Fields) Get(key string) (string, bool) {
+	for _, field := range f {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parser - splits the lines of a structured log format into their fields.
+type Parser interface {
+	Parse(line string) (Fields, bool)
+}
+
+// Parsers - parsers tried in order, the first one parsing a line wins.
+type Parsers []Parser
+
+// Parse - returns the fields of the plain `line` as parsed by the first
+// parser able to.
+func (p Parsers) Parse(line string) (Fields, bool) {
+	for _, parser := range p {
+		if fields, ok := parser.Parse(line); ok {
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+// NewParser - returns the parser of the `format`, one of `json`, `logfmt` or
+// `regex` for which the `pattern` holds named groups as fields.
+func NewParser(format, pattern string) (Parser, error) {
+	switch format {
+	case "json":
+		return jsonParser{}, nil
+	case "logfmt":
+		return logfmtParser{}, nil
+	case "regex":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return regexParser{re}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// Default - returns the parsers of JSON and logfmt lines.
+func Default() Parsers {
+	return Parsers{jsonParser{}, logfmtParser{}}
+}
+
+// ----------------------- PRIVATE
+
+// jsonParser - parses lines holding a JSON object. Nested values are kept as
+// compact JSON.
+type jsonParser struct{}
+
+func (jsonParser) Parse(line string) (Fields, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(line))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+
+	var fields Fields
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, false
+		}
+
+		fields = append(fields, Field{Key: token.(string), Value: jsonValue(raw)})
+	}
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('}') || decoder.More() {
+		return nil, false
+	}
+	return fields, true
+}
+
+// jsonValue - returns the text of a JSON value, unquoted for strings.
+func jsonValue(raw json.RawMessage) string {
+	var text string
+	if bytes.HasPrefix(raw, []byte(`"`)) && json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) != nil {
+		return string(raw)
+	}
+	return compact.String()
+}
+
+// logfmtParser - parses lines made only of `key=value` pairs, values being
+// quoted if they hold spaces.
+type logfmtParser struct{}
+
+func (logfmtParser) Parse(line string) (Fields, bool) {
+	var fields Fields
+
+	rest := strings.TrimSpace(line)
+	for len(rest) > 0 {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 || strings.ContainsAny(rest[:eq], " \t\"") {
+			return nil, false
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			end := closingQuote(rest)
+			if end < 0 {
+				return nil, false
+			}
+			unquoted, err := strconv.Unquote(rest[:end+1])
+			if err != nil {
+				return nil, false
+			}
+			value, rest = unquoted, rest[end+1:]
+		} else if end := strings.IndexAny(rest, " \t"); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+
+		if len(rest) > 0 && rest[0] != ' ' && rest[0] != '\t' {
+			return nil, false
+		}
+		rest = strings.TrimLeft(rest, " \t")
+		fields = append(fields, Field{Key: key, Value: value})
+	}
+
+	return fields, len(fields) > 0
+}
+
+// closingQuote - returns the index of the quote closing the one `text` starts
+// with, -1 if none.
+func closingQuote(text string) int {
+	for i := 1; i < len(text); i += 1 {
+		switch text[i] {
+		case '\\':
+			i += 1
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// regexParser - parses the lines matching a regex, its named groups being
+// the fields.
+type regexParser struct {
+	re *regexp.Regexp
+}
+
+func (p regexParser) Parse(line string) (Fields, bool) {
+	match := p.re.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+
+	var fields Fields
+	for i, name := range p.re.SubexpNames() {
+		if len(name) > 0 {
+			fields = append(fields, Field{Key: name, Value: match[i]})
+		}
+	}
+	return fields, len(fields) > 0
+}
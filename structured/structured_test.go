@@ -0,0 +1,108 @@
+package structured
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_ParseTableDriven(t *testing.T) {
+	re, err := NewParser("regex", `^(?P<time>\S+) \[(?P<level>\w+)\] (?P<msg>.*)$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsers := append(Parsers{re}, Default()...)
+
+	for _, test := range []struct {
+		name     string
+		line     string
+		expected string // fields as key:value, "" if not parsed
+	}{
+		{
+			name:     "json in order",
+			line:     `{"time":"10:00","level":"info","msg":"started","port":8080}`,
+			expected: "time:10:00 level:info msg:started port:8080",
+		},
+		{
+			name:     "json nested",
+			line:     ` {"msg": "ok", "ctx": {"user": 1, "tags": ["a", "b"]}, "err": null}`,
+			expected: `msg:ok ctx:{"user":1,"tags":["a","b"]} err:null`,
+		},
+		{
+			name:     "json broken",
+			line:     `{"msg": "ok"`,
+			expected: "",
+		},
+		{
+			name:     "json trailing text",
+			line:     `{"msg": "ok"} and more`,
+			expected: "",
+		},
+		{
+			name:     "logfmt",
+			line:     `time=10:00 level=warn msg="disk almost full" used=91%`,
+			expected: "time:10:00 level:warn msg:disk almost full used:91%",
+		},
+		{
+			name:     "logfmt escaped quote",
+			line:     `msg="say \"hi\"" empty=`,
+			expected: `msg:say "hi" empty:`,
+		},
+		{
+			name:     "logfmt with free text",
+			line:     `INFO started port=8080`,
+			expected: "",
+		},
+		{
+			name:     "logfmt unterminated quote",
+			line:     `msg="oops`,
+			expected: "",
+		},
+		{
+			name:     "regex first",
+			line:     `10:00 [error] level=fatal`,
+			expected: "time:10:00 level:error msg:level=fatal",
+		},
+		{
+			name:     "plain text",
+			line:     "just some text",
+			expected: "",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fields, ok := parsers.Parse(test.line)
+
+			var actual []string
+			for _, field := range fields {
+				actual = append(actual, fmt.Sprintf("%s:%s", field.Key, field.Value))
+			}
+
+			if ok != (len(test.expected) > 0) || strings.Join(actual, " ") != test.expected {
+				t.Errorf("expected %q, got %q (parsed %v)", test.expected, strings.Join(actual, " "), ok)
+			}
+		})
+	}
+}
+
+func Test_NewParserErrors(t *testing.T) {
+	if _, err := NewParser("xml", ""); err == nil {
+		t.Error("expected an unknown format error")
+	}
+	if _, err := NewParser("regex", "("); err == nil {
+		t.Error("expected an invalid pattern error")
+	}
+}
+
+func Test_FieldsGet(t *testing.T) {
+	fields := Fields{{"level", "info"}, {"msg", ""}}
+
+	if value, ok := fields.Get("level"); !ok || value != "info" {
+		t.Errorf("expected level info, got %q %v", value, ok)
+	}
+	if _, ok := fields.Get("msg"); !ok {
+		t.Error("expected empty values to be found")
+	}
+	if _, ok := fields.Get("time"); ok {
+		t.Error("expected time to be missing")
+	}
+}
@@ -0,0 +1,244 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/keymap"
+	"github.com/SpandanBG/logctrl/reader"
+	ui "github.com/SpandanBG/logctrl/ui/utils"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ----- Public tea.Msg
+type TeaBookmarksToggle struct {
+	BringFocus bool
+}
+
+// TeaBookmarkNoteDone - notifies that the note of a bookmark is no longer
+// being typed
+type TeaBookmarkNoteDone struct{}
+
+var (
+	bookmarksStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), true, false, false, false).
+			BorderForeground(lipgloss.Color("63"))
+	bookmarksTitleStyle = lipgloss.NewStyle().
+				Bold(true)
+)
+
+type bookmarks struct {
+	width    ui.SizeI
+	height   ui.SizeI
+	size     tea.WindowSizeMsg
+	session  reader.Session    // session holding the bookmarks
+	list     []reader.Bookmark // bookmarks as last read from the session
+	selected int               // index of the selected bookmark in `list`
+	note     textinput.Model   // note editor of the bookmark on `noteLine`
+	noteLine int               // line whose note is edited, -1 when not editing
+}
+
+func NewBookmarks(width, height ui.SizeI, session reader.Session) tea.Model {
+	note := textinput.New()
+	note.Prompt = "note: "
+
+	return bookmarks{
+		width:    width,
+		height:   height,
+		session:  session,
+		list:     session.Bookmarks(),
+		note:     note,
+		noteLine: -1,
+	}
+}
+
+func (b bookmarks) Init() tea.Cmd {
+	return tea.Batch(
+		tea.WindowSize(),
+	)
+}
+
+func (b bookmarks) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case keymap.ActionMsg:
+		return b.executeAction(msg.Name)
+	case tea.KeyMsg:
+		return b.receiveNote(msg)
+	case tea.WindowSizeMsg:
+		return b.updateView(msg)
+	case TeaBookmarksToggle:
+		return b.setFocus(msg)
+	case TeaBookmarksChanged:
+		return b.reload()
+	case TeaBookmarkNote:
+		return b.editNote(msg.Line)
+	case TeaMouseWheel:
+		b.selected = max(min(b.selected+msg.Delta, len(b.list)-1), 0)
+	case TeaMouseClick:
+		return b.click(msg.Y)
+	}
+
+	return b, nil
+}
+
+func (b bookmarks) View() string {
+	rows := []string{bookmarksTitleStyle.Render(
+		fmt.Sprintf("Bookmarks (%d)", len(b.list)),
+	)}
+
+	if b.noteLine >= 0 {
+		rows = append(rows, b.note.View())
+	}
+
+	start, visible := b.shown(len(rows))
+	for i := start; i < len(b.list) && i < start+visible; i += 1 {
+		row := fmt.Sprintf("%6d  %s", b.list[i].Line+1, b.list[i].Note)
+		if i == b.selected {
+			row = cursorStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	return bookmarksStyle.
+		Width(b.size.Width).
+		Height(b.size.Height - bookmarksStyle.GetVerticalFrameSize()).
+		Render(strings.Join(rows, "\n"))
+}
+
+// ------------------------- Private
+func (b bookmarks) executeAction(action string) (tea.Model, tea.Cmd) {
+	switch action {
+	case keymap.ScrollUp:
+		b.selected = max(b.selected-1, 0)
+	case keymap.ScrollDown:
+		b.selected = max(min(b.selected+1, len(b.list)-1), 0)
+	case keymap.JumpToBookmark:
+		return b.jump()
+	case keymap.EditBookmark:
+		return b.requestNote()
+	case keymap.DeleteBookmark:
+		return b.delete()
+	case keymap.Submit:
+		err := b.session.SetBookmarkNote(b.noteLine, b.note.Value())
+		model, cmd := b.endNote()
+		return model, tea.Batch(cmd, func() tea.Msg { return TeaBookmarksSaved{Err: err} })
+	case keymap.ClosePrompt:
+		return b.endNote()
+	}
+
+	return b, nil
+}
+
+// receiveNote - types the key into the note being edited.
+func (b bookmarks) receiveNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if b.noteLine < 0 {
+		return b, nil
+	}
+
+	var cmd tea.Cmd
+	b.note, cmd = b.note.Update(msg)
+	return b, cmd
+}
+
+// requestNote - asks to edit the note of the selected bookmark.
+func (b bookmarks) requestNote() (tea.Model, tea.Cmd) {
+	if len(b.list) == 0 {
+		return b, nil
+	}
+
+	line := b.list[b.selected].Line
+	return b, func() tea.Msg { return TeaBookmarkNote{Line: line} }
+}
+
+func (b bookmarks) endNote() (tea.Model, tea.Cmd) {
+	b.noteLine = -1
+	b.note.Blur()
+	return b, func() tea.Msg { return TeaBookmarkNoteDone{} }
+}
+
+func (b bookmarks) updateView(size tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
+	b.size = ui.ModifySize(size, b.width, b.height)
+	b.note.Width = max(b.size.Width-len(b.note.Prompt)-1, 0)
+	return b, nil
+}
+
+func (b bookmarks) setFocus(data TeaBookmarksToggle) (tea.Model, tea.Cmd) {
+	if !data.BringFocus {
+		b.noteLine = -1
+		b.note.Blur()
+	}
+	return b.reload()
+}
+
+// reload - reads the bookmarks again from the session.
+func (b bookmarks) reload() (tea.Model, tea.Cmd) {
+	b.list = b.session.Bookmarks()
+	b.selected = max(min(b.selected, len(b.list)-1), 0)
+	return b, nil
+}
+
+// editNote - starts editing the note of the bookmark on `line`.
+func (b bookmarks) editNote(line int) (tea.Model, tea.Cmd) {
+	b.noteLine = line
+	b.note.SetValue("")
+
+	for i, bookmark := range b.list {
+		if bookmark.Line == line {
+			b.selected = i
+			b.note.SetValue(bookmark.Note)
+		}
+	}
+
+	return b, b.note.Focus()
+}
+
+// shown - returns the first bookmark shown below the `header` rows and how
+// many fit, keeping the selected one in view.
+func (b bookmarks) shown(header int) (int, int) {
+	visible := max(b.size.Height-bookmarksStyle.GetVerticalFrameSize()-header, 0)
+	return max(b.selected-visible+1, 0), visible
+}
+
+// click - selects the bookmark on the row `y` of the pane, or jumps to it if
+// it was already selected.
+func (b bookmarks) click(y int) (tea.Model, tea.Cmd) {
+	if b.noteLine >= 0 {
+		return b, nil
+	}
+
+	header := 1
+	start, visible := b.shown(header)
+	row := y - bookmarksStyle.GetBorderTopSize() - header
+	if row < 0 || row >= visible || start+row >= len(b.list) {
+		return b, nil
+	}
+
+	if start+row == b.selected {
+		return b.jump()
+	}
+	b.selected = start + row
+	return b, nil
+}
+
+func (b bookmarks) jump() (tea.Model, tea.Cmd) {
+	if len(b.list) == 0 {
+		return b, nil
+	}
+
+	line := b.list[b.selected].Line
+	return b, func() tea.Msg { return TeaBookmarkJump{Line: line} }
+}
+
+func (b bookmarks) delete() (tea.Model, tea.Cmd) {
+	if len(b.list) == 0 {
+		return b, nil
+	}
+
+	_, err := b.session.ToggleBookmark(b.list[b.selected].Line, "")
+	return b, tea.Batch(
+		func() tea.Msg { return TeaBookmarksChanged{} },
+		func() tea.Msg { return TeaBookmarksSaved{Err: err} },
+	)
+}
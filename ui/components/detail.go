@@ -0,0 +1,285 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SpandanBG/logctrl/keymap"
+	"github.com/SpandanBG/logctrl/reader"
+	"github.com/SpandanBG/logctrl/structured"
+	ui "github.com/SpandanBG/logctrl/ui/utils"
+	"github.com/SpandanBG/logctrl/utils"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ----- Public tea.Msg
+
+// TeaLineSelect - notifies the session line under the cursor of the log view
+type TeaLineSelect struct {
+	Line int
+}
+
+const detailIndent = "  "
+
+var (
+	detailStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), true, false, false, false).
+			BorderForeground(lipgloss.Color("63"))
+	detailKeyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("12"))
+	detailStringStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("10"))
+	detailNumberStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("13"))
+	detailLiteralStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("11"))
+)
+
+type detail struct {
+	width   ui.SizeI
+	height  ui.SizeI
+	size    tea.WindowSizeMsg
+	session reader.Session     // session holding the lines
+	parsers structured.Parsers // parse the lines into their fields
+	line    int                // session line shown, -1 if none
+	heading string             // rendered title of the line shown
+	rows    []string           // rendered record of the line shown
+	view    viewport.Model     // scrolls the rows
+}
+
+func NewDetail(
+	width, height ui.SizeI,
+	session reader.Session,
+	parsers structured.Parsers,
+) tea.Model {
+	d := detail{
+		width:   width,
+		height:  height,
+		session: session,
+		parsers: parsers,
+		line:    -1,
+		view:    viewport.New(0, 0),
+	}
+	return d.load()
+}
+
+func (d detail) Init() tea.Cmd {
+	return tea.Batch(
+		tea.WindowSize(),
+	)
+}
+
+func (d detail) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.size = ui.ModifySize(msg, d.width, d.height)
+		d.view.Width = d.size.Width
+		d.view.Height = max(d.size.Height-detailStyle.GetVerticalFrameSize()-1, 0)
+		d.view.SetContent(d.content())
+	case TeaLineSelect:
+		d.line = msg.Line
+		return d.load(), nil
+	case TeaMouseWheel:
+		d.scroll(msg.Delta * wheelStep)
+	case keymap.ActionMsg:
+		switch msg.Name {
+		case keymap.ScrollDetailUp:
+			d.scroll(-1)
+		case keymap.ScrollDetailDown:
+			d.scroll(1)
+		}
+	}
+
+	return d, nil
+}
+
+func (d detail) View() string {
+	height := max(d.size.Height-detailStyle.GetVerticalFrameSize(), 0)
+
+	return detailStyle.
+		Width(d.size.Width).
+		Height(height).
+		Render(ansi.Truncate(d.heading, d.size.Width, "…") + "\n" + d.view.View())
+}
+
+// ------------------------- Private
+
+// load - renders the line selected and its record, shown from the top.
+func (d detail) load() detail {
+	if d.line < 0 || d.line >= d.session.Len() {
+		d.heading = bookmarksTitleStyle.Render("Detail")
+		d.rows = []string{gutterStyle.Render("no line selected")}
+	} else {
+		d.heading = d.title()
+		d.rows = d.record()
+	}
+
+	d.view.SetContent(d.content())
+	d.view.GotoTop()
+	return d
+}
+
+// content - returns the rows cut to the width of the pane.
+func (d detail) content() string {
+	rows := make([]string, len(d.rows))
+	for i, row := range d.rows {
+		rows[i] = ansi.Truncate(row, d.size.Width, "…")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// scroll - scrolls the rows by `delta`, up if negative.
+func (d *detail) scroll(delta int) {
+	if delta < 0 {
+		d.view.ScrollUp(-delta)
+	} else {
+		d.view.ScrollDown(delta)
+	}
+}
+
+// title - returns the line number of the record shown, with when and where
+// the line was read from.
+func (d detail) title() string {
+	meta := "read from the session " + d.session.Path()
+	if at := d.session.Time(d.line); !at.IsZero() {
+		meta = "appended " + at.Format(time.TimeOnly+".000") + " from the log feed"
+	}
+
+	if from, to := d.session.Record(d.line); to-from > 1 {
+		meta += fmt.Sprintf(", record of %d lines", to-from)
+	}
+
+	return bookmarksTitleStyle.Render(fmt.Sprintf("Line %d", d.line+1)) + " " + gutterStyle.Render(meta)
+}
+
+// record - renders the selected line pretty printed, as indented JSON or its
+// fields sorted by name, followed by the other lines of its record.
+func (d detail) record() []string {
+	from, to := d.session.Record(d.line)
+	if from != d.line {
+		from, to = d.line, d.line+1
+	}
+
+	lines := d.session.Lines(from, to)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	first := plainText(lines[0])
+	if trimmed := strings.TrimSpace(first); json.Valid([]byte(trimmed)) && strings.HasPrefix(trimmed, "{") {
+		prettyJSON(&b, trimmed, 0)
+	} else if fields, ok := d.parsers.Parse(first); ok {
+		prettyFields(&b, fields)
+	} else {
+		b.WriteString(utils.Sanitize(first))
+	}
+
+	rows := strings.Split(b.String(), "\n")
+	for _, line := range lines[1:] {
+		rows = append(rows, utils.Sanitize(plainText(line)))
+	}
+	return rows
+}
+
+// prettyFields - writes the `fields` sorted by name, one per line with their
+// names aligned. Values holding JSON are decoded.
+func prettyFields(b *strings.Builder, fields structured.Fields) {
+	fields = slices.Clone(fields)
+	slices.SortStableFunc(fields, func(a, b structured.Field) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	width := 0
+	for _, field := range fields {
+		width = max(width, ansi.StringWidth(field.Key))
+	}
+
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		key := utils.Sanitize(field.Key)
+		b.WriteString(detailKeyStyle.Render(key) + strings.Repeat(" ", width-ansi.StringWidth(key)) + " = ")
+		if nested, ok := nestedJSON(field.Value); ok {
+			prettyJSON(b, nested, 1)
+		} else {
+			b.WriteString(utils.Sanitize(field.Value))
+		}
+	}
+}
+
+// prettyJSON - writes the valid JSON `text` indented from `depth`, colored and
+// with the keys in their order. Strings holding JSON are decoded in place.
+func prettyJSON(b *strings.Builder, text string, depth int) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	writeJSON(b, decoder, depth)
+}
+
+// writeJSON - writes the next value of the `decoder` indented from `depth`.
+func writeJSON(b *strings.Builder, decoder *json.Decoder, depth int) {
+	token, err := decoder.Token()
+	if err != nil {
+		return
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		closing := "}"
+		if token == '[' {
+			closing = "]"
+		}
+
+		b.WriteString(token.String())
+		empty := !decoder.More()
+		for i := 0; decoder.More(); i += 1 {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n" + strings.Repeat(detailIndent, depth+1))
+
+			if token == '{' {
+				key, _ := decoder.Token()
+				name, _ := key.(string)
+				b.WriteString(detailKeyStyle.Render(strconv.Quote(name)) + ": ")
+			}
+			writeJSON(b, decoder, depth+1)
+		}
+		decoder.Token() // the closing delimiter
+
+		if !empty {
+			b.WriteString("\n" + strings.Repeat(detailIndent, depth))
+		}
+		b.WriteString(closing)
+	case string:
+		if nested, ok := nestedJSON(token); ok {
+			prettyJSON(b, nested, depth)
+			return
+		}
+		b.WriteString(detailStringStyle.Render(strconv.Quote(token)))
+	case json.Number:
+		b.WriteString(detailNumberStyle.Render(token.String()))
+	case bool:
+		b.WriteString(detailLiteralStyle.Render(strconv.FormatBool(token)))
+	case nil:
+		b.WriteString(detailLiteralStyle.Render("null"))
+	}
+}
+
+// nestedJSON - returns the JSON object or array held by the string `value`.
+func nestedJSON(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "[") {
+		return "", false
+	}
+	return value, json.Valid([]byte(value))
+}
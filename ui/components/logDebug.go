@@ -0,0 +1,67 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	debugStyle = lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Foreground(lipgloss.Color("15"))
+)
+
+// renderStats - measures of the rendering shown in the debug overlay.
+type renderStats struct {
+	render time.Duration // time taken by the last render
+	framed time.Time     // when the last frame of new logs started
+	lines  int           // lines read when the last frame of new logs started
+	rate   float64       // lines read per second between the last two frames
+}
+
+// ------------------------- Private
+
+// frameTime - returns the least time between two frames at `fps` frames per
+// second, 0 for no limit.
+func frameTime(fps int) time.Duration {
+	if fps <= 0 {
+		return 0
+	}
+	return time.Second / time.Duration(fps)
+}
+
+// frame - starts a frame rendering the new logs, `lines` being read `now`.
+func (s renderStats) frame(lines int, now time.Time) renderStats {
+	if elapsed := now.Sub(s.framed).Seconds(); !s.framed.IsZero() && elapsed > 0 {
+		s.rate = float64(lines-s.lines) / elapsed
+	}
+	s.framed, s.lines = now, lines
+	return s
+}
+
+// overlay - draws the render measures over the top right of the `content`.
+func (l logView) overlay(content string) string {
+	limit := "no fps limit"
+	if l.frame > 0 {
+		limit = fmt.Sprintf("%d fps max", time.Second/l.frame)
+	}
+
+	text := debugStyle.Render(fmt.Sprintf(
+		" render %s · %s · %d lines · %.0f lines/s ",
+		l.stats.render.Round(time.Microsecond), limit, l.stream.Session().Len(), l.stats.rate,
+	))
+	width := ansi.StringWidth(text)
+
+	rows := strings.SplitN(content, "\n", 2)
+	if width > l.view.Width {
+		text, width = ansi.Truncate(text, l.view.Width, ""), l.view.Width
+	}
+
+	first := closeStyles(ansi.Truncate(rows[0], l.view.Width-width, ""))
+	rows[0] = first + strings.Repeat(" ", max(l.view.Width-width-ansi.StringWidth(first), 0)) + text
+	return strings.Join(rows, "\n")
+}
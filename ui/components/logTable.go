@@ -0,0 +1,299 @@
+package components
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/highlight"
+	"github.com/SpandanBG/logctrl/structured"
+	"github.com/SpandanBG/logctrl/utils"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// TeaColumnsSet - chooses and orders the fields shown in the table mode, nil
+// shows them all
+type TeaColumnsSet struct {
+	Fields []string
+}
+
+// TeaSortSet - sorts the rows of the history view by the field, "" clears it
+type TeaSortSet struct {
+	Field      string
+	Descending bool
+}
+
+// teaRowsSorted - notifies the rows sorted in the background for a sort
+// request
+type teaRowsSorted struct {
+	request int   // sort request the rows are sorted for
+	sorted  []int // session lines in sort order, one per row
+	lines   int   // session lines when the rows were taken
+	keep    int   // session line to move the cursor to, -1 for the first row
+}
+
+const (
+	cellGap      = "  "
+	maxCellWidth = 40 // columns taken at most by a cell, but the last one
+)
+
+// ------------------------- Private
+
+// tableColumns - returns the fields shown in the table mode.
+func (l logView) tableColumns() []string {
+	if l.shown != nil {
+		return l.shown
+	}
+	return l.found
+}
+
+// fields - returns the fields of the session `line`, if it is structured.
+func (l logView) fields(line string) (structured.Fields, bool) {
+	return l.parsers.Parse(plainText(line))
+}
+
+// sizeColumns - sizes the columns to the rows from `top` that fit the view,
+// adding the fields met for the first time to those found.
+func (l logView) sizeColumns(top int) logView {
+	session := l.stream.Session()
+
+	rows := make([]structured.Fields, 0, l.view.Height)
+	for row := top; row < l.rows() && row < top+l.view.Height; row += 1 {
+		if fields, ok := l.fields(session.Line(l.lineAt(row))); ok {
+			rows = append(rows, fields)
+		}
+	}
+
+	for _, fields := range rows {
+		for _, field := range fields {
+			if !slices.Contains(l.found, field.Key) {
+				l.found = append(l.found[:len(l.found):len(l.found)], field.Key)
+			}
+		}
+	}
+
+	l.widths = map[string]int{}
+	for _, column := range l.tableColumns() {
+		width := ansi.StringWidth(column)
+		for _, fields := range rows {
+			value, _ := fields.Get(column)
+			width = max(width, ansi.StringWidth(utils.Sanitize(value)))
+		}
+		l.widths[column] = min(width, maxCellWidth)
+	}
+
+	return l
+}
+
+// renderRow - renders the session `line`, as projected by the filter, as a
+// row of the table if it is structured, or as is spanning the row if not.
+func (l logView) renderRow(rules highlight.Rules, line string) string {
+	line = l.projected(line)
+	if !l.table {
+		return l.renderLine(rules, line)
+	}
+
+	fields, ok := l.fields(line)
+	if !ok {
+		return l.renderLine(rules, line)
+	}
+
+	columns := l.tableColumns()
+	cells := make([]string, len(columns))
+	for i, column := range columns {
+		value, _ := fields.Get(column)
+		cells[i] = l.cell(utils.Sanitize(value), column, i == len(columns)-1)
+	}
+	return rules.Apply(strings.Join(cells, cellGap))
+}
+
+// rowWidth - returns the columns taken by the session `line` once rendered.
+func (l logView) rowWidth(line string) int {
+	if l.table {
+		if _, ok := l.fields(l.projected(line)); ok {
+			return ansi.StringWidth(l.renderRow(nil, line))
+		}
+	}
+	return l.lineWidth(l.projected(line))
+}
+
+// cell - returns the `value` padded or cut to the width of the `column`,
+// kept whole in the `last` column.
+func (l logView) cell(value, column string, last bool) string {
+	if last {
+		return value
+	}
+
+	width := l.widths[column]
+	if ansi.StringWidth(value) > width {
+		value = ansi.Truncate(value, width, "…")
+	}
+	return value + strings.Repeat(" ", max(width-ansi.StringWidth(value), 0))
+}
+
+// topBorder - renders the top border of the view, naming the columns in the
+// table mode.
+func (l logView) topBorder() string {
+	border := logViewStyle.GetBorderStyle()
+	style := lipgloss.NewStyle().Foreground(logViewStyle.GetBorderTopForeground())
+
+	header := ""
+	if l.table && len(l.tableColumns()) > 0 {
+		columns := l.tableColumns()
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = l.cell(column, column, i == len(columns)-1)
+		}
+
+		header = strings.Join(cells, cellGap)
+		if !l.wrap {
+			header = ansi.Cut(header, l.xOffset, l.xOffset+l.columns)
+		}
+		header = strings.Repeat(border.Top, l.gutterWidth(l.stream.Session().Len())+1) + header
+		header = ansi.Truncate(header, l.view.Width, "")
+	}
+
+	fill := strings.Repeat(border.Top, max(l.view.Width-ansi.StringWidth(header), 0))
+	header = strings.ReplaceAll(header, " ", border.Top)
+	return style.Render(border.TopLeft + header + fill + border.TopRight)
+}
+
+// setColumns - shows the `fields` in the table mode, all of them if nil.
+func (l logView) setColumns(fields []string) (tea.Model, tea.Cmd) {
+	l.shown = fields
+	l.table = true
+	return l.redraw()
+}
+
+// setSort - sorts the rows by the `field`, switching to the history view once
+// sorted, or clears the sort if the field is empty.
+func (l logView) setSort(field string, descending bool) (tea.Model, tea.Cmd) {
+	line := 0
+	if !l.follow && l.rows() > 0 {
+		line = l.lineAt(l.cursor)
+	}
+
+	l.sortBy, l.sortDesc = field, descending
+	l.anchor = -1
+	if len(field) > 0 {
+		line = -1
+	}
+	l, sort := l.sortRows(line)
+
+	model, cmd := l.redraw()
+	if len(field) == 0 && !l.follow {
+		model, cmd = l.jumpToLine(line)
+	}
+	return model, tea.Batch(cmd, sort)
+}
+
+// sortRows - starts ordering the rows by the field sorted by, in the
+// background as every row is read and parsed. The rows are shown unsorted
+// meanwhile, the cursor moving to the `keep` line once sorted, or to the
+// first row if negative. The lines of a record are kept together, sorted by
+// the first one. Values are compared as numbers when both are, lines missing
+// the field come last.
+func (l logView) sortRows(keep int) (logView, tea.Cmd) {
+	l.sorted = nil
+	l.sortReq += 1
+	if len(l.sortBy) == 0 {
+		return l, nil
+	}
+
+	l = l.scan()
+	rows := make([]int, l.rows())
+	for row := range rows {
+		rows[row] = l.lineAt(row)
+	}
+	lines := len(rows)
+	if l.indexed() {
+		lines = l.scanned
+	}
+
+	session, parsers := l.stream.Session(), l.parsers
+	by, descending, request := l.sortBy, l.sortDesc, l.sortReq
+	l.status = "sorting by " + by + "…"
+
+	return l, func() tea.Msg {
+		type record struct {
+			lines []int
+			value string
+			found bool
+		}
+
+		var records []record
+		for _, line := range rows {
+			if start, _ := session.Record(line); start != line && len(records) > 0 {
+				last := &records[len(records)-1]
+				last.lines = append(last.lines, line)
+				continue
+			}
+
+			fields, _ := parsers.Parse(plainText(session.Line(line)))
+			value, found := fields.Get(by)
+			records = append(records, record{lines: []int{line}, value: value, found: found})
+		}
+
+		slices.SortStableFunc(records, func(a, b record) int {
+			switch {
+			case a.found != b.found && a.found:
+				return -1
+			case a.found != b.found:
+				return 1
+			}
+
+			order := compareValues(a.value, b.value)
+			if descending {
+				return -order
+			}
+			return order
+		})
+
+		sorted := make([]int, 0, len(rows))
+		for _, r := range records {
+			sorted = append(sorted, r.lines...)
+		}
+		return teaRowsSorted{request: request, sorted: sorted, lines: lines, keep: keep}
+	}
+}
+
+// showSorted - shows the rows sorted in the background, unless sorted again
+// or cleared since.
+func (l logView) showSorted(msg teaRowsSorted) (tea.Model, tea.Cmd) {
+	if msg.request != l.sortReq || len(l.sortBy) == 0 {
+		return l, nil
+	}
+
+	l.sorted, l.sortedAt = msg.sorted, msg.lines
+	l.status = ""
+	if msg.keep < 0 {
+		return l.jumpTo(0)
+	}
+	return l.jumpToLine(msg.keep)
+}
+
+// unsortedText - returns the status noting the lines read since the rows
+// were sorted, not shown until sorted again, "" if none.
+func (l logView) unsortedText() string {
+	if l.sorted == nil {
+		return ""
+	}
+	if unsorted := l.stream.Session().Len() - l.sortedAt; unsorted > 0 {
+		return fmt.Sprintf("%d new lines not sorted", unsorted)
+	}
+	return ""
+}
+
+// compareValues - compares two field values, as numbers if both are.
+func compareValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return strings.Compare(a, b)
+}
@@ -1,11 +1,28 @@
 package components
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SpandanBG/logctrl/cmd"
+	"github.com/SpandanBG/logctrl/config"
+	"github.com/SpandanBG/logctrl/highlight"
+	"github.com/SpandanBG/logctrl/keymap"
 	"github.com/SpandanBG/logctrl/reader"
+	"github.com/SpandanBG/logctrl/structured"
 	ui "github.com/SpandanBG/logctrl/ui/utils"
+	"github.com/SpandanBG/logctrl/utils"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // ----- Public tea.Msg
@@ -14,35 +31,178 @@ type TeaLogSizeUpdate struct {
 	Height ui.SizeI
 }
 
+// TeaBookmarkJump - moves the history view cursor to the bookmarked line
+type TeaBookmarkJump struct {
+	Line int
+}
+
+// TeaBookmarkNote - asks for a note to bookmark the line with
+type TeaBookmarkNote struct {
+	Line int
+}
+
+// TeaBookmarksChanged - notifies that the bookmarks of the session changed
+type TeaBookmarksChanged struct{}
+
+// TeaHighlightAdd - adds a highlight rule on top of the existing ones
+type TeaHighlightAdd struct {
+	Rule highlight.Rule
+}
+
+// TeaFilterSet - shows only the lines matching the filter, nil clears it
+type TeaFilterSet struct {
+	Filter cmd.Filter
+}
+
+// TeaSearchSet - highlights the matches of the pattern and moves to the
+// closest one, nil clears it
+type TeaSearchSet struct {
+	Pattern *regexp.Regexp
+}
+
+// TeaGoto - moves the cursor to the 1 based line, `cmd.LineTop` or
+// `cmd.LineEnd`
+type TeaGoto struct {
+	Line int
+}
+
+// TeaSave - writes the lines shown into the file at the path
+type TeaSave struct {
+	Path string
+}
+
+// TeaOptionSet - changes an option of the view, a nil value reports it
+type TeaOptionSet struct {
+	Name  string
+	Value any
+}
+
 // ----- Private tea.Msg
-type teaLogCmd string
+
+// teaLogCmd - notifies the number of lines read from the stream so far
+type teaLogCmd struct {
+	lines int
+}
+
+// teaStreamEnd - notifies that the stream is read, nothing new will come
+type teaStreamEnd struct{}
+
+// recordFilter - a filter matching the records by their first line in the
+// session, known when they were read, instead of by their text.
+type recordFilter interface {
+	MatchRecord(int) bool
+}
+
+const (
+	bookmarkMarker = "▍"
+	wrapMarker     = "↪"
+	foldMarker     = " [+%d lines]"
+	repeatMarker   = " ×%d"
+	repeatSeen     = " (last %s)"
+	scrollStep     = 8     // columns moved by a horizontal scroll
+	heightsKept    = 10000 // row heights cached at most, measured again once exceeded
+)
 
 var (
 	logViewStyle = lipgloss.NewStyle().
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("63"))
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color("63"))
+	cursorStyle = lipgloss.NewStyle().
+			Reverse(true)
+	gutterStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8"))
+	bookmarkStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+	searchStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("11")).
+			Foreground(lipgloss.Color("0"))
 )
 
 type logView struct {
-	width   ui.SizeI        // width modifier
-	height  ui.SizeI        // height modifier
-	view    viewport.Model  // holds the viewport
-	stream  reader.Stream // log feed stream to be displayed
-	ready   bool            // if `true` the viewport is ready to render
-	nextLog chan bool       // notification channel from stream
+	width    ui.SizeI       // width modifier
+	height   ui.SizeI       // height modifier
+	view     viewport.Model // holds the viewport
+	stream   reader.Stream  // log feed stream to be displayed
+	ready    bool           // if `true` the viewport is ready to render
+	nextLog  <-chan int     // notification channel from stream
+	follow   bool           // if `true` the live logs are tailed
+	cursor   int            // row under the cursor in the history view
+	top      int            // first row shown in the history view
+	rules    highlight.Rules
+	numbers  bool               // if `true` line numbers are shown
+	filter   cmd.Filter         // if set only the matching lines are shown
+	index    []int              // session lines matching the filter, one per row
+	scanned  int                // session lines checked against the filter
+	search   *regexp.Regexp     // pattern highlighted and jumped to
+	wrap     bool               // if `true` long lines are wrapped, else scrolled sideways
+	xOffset  int                // first column shown when not wrapping
+	widest   int                // width of the widest line last rendered
+	columns  int                // columns left for the text of a line
+	colors   bool               // if `false` the colors of the lines are stripped
+	maxLine  int                // bytes shown of a line, 0 for no limit
+	foldAll  bool               // if `true` every record is folded
+	folds    map[int]bool       // records, by first line, folded unlike the others
+	collapse bool               // if `true` repeated records are shown once
+	repeats  map[int]int        // records collapsed into the record at a line
+	repeated bool               // if `true` the last record scanned was collapsed
+	table    bool               // if `true` structured lines are shown as a table
+	parsers  structured.Parsers // parse the lines into the fields of the table
+	shown    []string           // fields shown in the table, all if nil
+	found    []string           // fields met so far, in order
+	widths   map[string]int     // width of each column, sized to the rows in view
+	sortBy   string             // field the rows are sorted by, "" for none
+	sortDesc bool               // if `true` the rows are sorted in descending order
+	sorted   []int              // session lines in sort order, one per row
+	sortReq  int                // sort requests made, the rows sorted for older ones are dropped
+	sortedAt int                // session lines when the rows were sorted
+	detail   bool               // if `true` the line under the cursor is shown in the detail pane
+	selected int                // session line last sent to the detail pane
+	anchor   int                // row the selection started at, -1 when not selecting
+	copyFmt  string             // format of the lines copied, one of `cmd.Copy*`
+	status   string             // shown on the bottom border until the next action
+	frame    time.Duration      // least time between two renders of new logs, 0 for no limit
+	stats    renderStats        // measures shown in the debug overlay
+	debug    bool               // if `true` the debug overlay is shown
+	ended    bool               // if `true` the stream is read to its end
+	heights  *rowHeights        // rows taken by the session lines when wrapped, measured once
 }
 
-func NewLogView(width, height ui.SizeI, stream reader.Stream) tea.Model {
-	nextLog := make(chan bool)
+// rowHeights - the rows of the view taken by session lines when wrapped at a
+// number of columns, shared by the copies of the view. A new one is made when
+// the text shown of the lines changes.
+type rowHeights struct {
+	columns int
+	lines   map[int]int
+}
 
+func NewLogView(
+	ctx context.Context,
+	width, height ui.SizeI,
+	stream reader.Stream,
+	rules highlight.Rules,
+	view config.View,
+	parsers structured.Parsers,
+) tea.Model {
 	stream.SetBufferSize(1)
-	stream.Start(nextLog)
+	nextLog := stream.Start(ctx)
 
 	return logView{
-		width:   width,
-		height:  height,
-		stream:  stream,
-		nextLog: nextLog,
+		width:    width,
+		height:   height,
+		stream:   stream,
+		nextLog:  nextLog,
+		follow:   true,
+		rules:    rules,
+		numbers:  true,
+		colors:   true,
+		maxLine:  view.MaxLineLength,
+		collapse: true,
+		parsers:  parsers,
+		shown:    view.Columns,
+		anchor:   -1,
+		copyFmt:  cmd.CopyText,
+		frame:    frameTime(view.MaxFPS),
+		heights:  &rowHeights{},
 	}
 }
 
@@ -55,25 +215,124 @@ func (l logView) Init() tea.Cmd {
 
 func (l logView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		return l.executeKey(msg.String())
+	case keymap.ActionMsg:
+		return l.executeAction(msg.Name)
 	case tea.WindowSizeMsg:
 		return l.updateViewSize(msg)
 	case teaLogCmd:
-		return l.refreshView(string(msg))
+		return l.refreshView(msg)
+	case TeaStreamError:
+		// the lines read before the error are shown, the end follows
+		model, cmd := l.redraw()
+		return model, tea.Batch(cmd, l.fetchLog())
+	case teaStreamEnd:
+		l.ended = true
+		return l.redraw()
 	case TeaLogSizeUpdate:
 		return l.updateSize(msg)
+	case TeaBookmarkJump:
+		return l.jumpToLine(msg.Line)
+	case TeaBookmarksChanged:
+		return l.redraw()
+	case TeaHighlightAdd:
+		return l.addHighlight(msg.Rule)
+	case TeaFilterSet:
+		return l.setFilter(msg.Filter)
+	case TeaSearchSet:
+		return l.setSearch(msg.Pattern)
+	case TeaGoto:
+		return l.gotoLine(msg.Line)
+	case TeaSave:
+		return l.save(msg.Path)
+	case TeaOptionSet:
+		return l.setOption(msg.Name, msg.Value)
+	case TeaColumnsSet:
+		return l.setColumns(msg.Fields)
+	case TeaSortSet:
+		return l.setSort(msg.Field, msg.Descending)
+	case teaRowsSorted:
+		return l.showSorted(msg)
+	case TeaMouseWheel:
+		l.status = ""
+		return l.moveCursor(msg.Delta * wheelStep)
+	case TeaMouseClick:
+		l.status = ""
+		return l.click(msg.Y)
 	}
 	return l, nil
 }
 
 func (l logView) View() string {
-	return logViewStyle.Render(l.view.View())
+	content := l.view.View()
+	if l.debug {
+		content = l.overlay(content)
+	}
+
+	content = logViewStyle.BorderTop(false).BorderBottom(false).Render(content)
+	return l.topBorder() + "\n" + content + "\n" + l.bottomBorder()
 }
 
 // ------------------------- Private
-func (l logView) executeKey(key string) (tea.Model, tea.Cmd) {
-	switch key {
+func (l logView) executeAction(action string) (tea.Model, tea.Cmd) {
+	l.status = ""
+
+	switch action {
+	case keymap.ScrollUp:
+		return l.moveCursor(-1)
+	case keymap.ScrollDown:
+		return l.moveCursor(1)
+	case keymap.PageUp:
+		return l.moveCursor(-l.view.Height)
+	case keymap.PageDown:
+		return l.moveCursor(l.view.Height)
+	case keymap.Top:
+		return l.jumpTo(0)
+	case keymap.Follow:
+		return l.followLive()
+	case keymap.Bookmark:
+		return l.toggleBookmark()
+	case keymap.BookmarkNote:
+		return l.noteBookmark()
+	case keymap.NextBookmark:
+		return l.jumpToBookmark(false)
+	case keymap.PrevBookmark:
+		return l.jumpToBookmark(true)
+	case keymap.SearchNext:
+		return l.jumpToMatch(false)
+	case keymap.SearchPrev:
+		return l.jumpToMatch(true)
+	case keymap.ToggleWrap:
+		return l.setWrap(!l.wrap)
+	case keymap.ToggleFold:
+		return l.toggleFold()
+	case keymap.ToggleFoldAll:
+		return l.toggleFoldAll()
+	case keymap.ToggleColors:
+		l.colors = !l.colors
+		l.heights = &rowHeights{}
+		return l.redraw()
+	case keymap.ToggleCollapse:
+		l.collapse = !l.collapse
+		return l.reindex()
+	case keymap.ToggleTable:
+		l.table = !l.table
+		return l.redraw()
+	case keymap.ToggleDetail:
+		return l.toggleDetail()
+	case keymap.ToggleDebug:
+		l.debug = !l.debug
+		return l, nil
+	case keymap.ToggleSelect:
+		return l.toggleSelect()
+	case keymap.Yank:
+		return l.yank()
+	case keymap.CancelSelect:
+		l.anchor = -1
+		return l.redraw()
+	case keymap.ScrollLeft:
+		return l.scrollSideways(-scrollStep)
+	case keymap.ScrollRight:
+		return l.scrollSideways(scrollStep)
 	default:
 		return l, nil
 	}
@@ -82,8 +341,9 @@ func (l logView) executeKey(key string) (tea.Model, tea.Cmd) {
 func (l logView) updateViewSize(size tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	// get relative size of view
 	size = ui.ModifySize(size, l.width, l.height)
-	w := size.Width - logViewStyle.GetHorizontalFrameSize()
-	h := size.Height - logViewStyle.GetVerticalFrameSize()
+	// the panels may take more than the screen when it is short
+	w := max(size.Width-logViewStyle.GetHorizontalFrameSize(), 0)
+	h := max(size.Height-logViewStyle.GetVerticalFrameSize(), 0)
 
 	// update view width and height
 	if l.ready {
@@ -94,21 +354,42 @@ func (l logView) updateViewSize(size tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 		l.ready = true
 	}
 
-	// set buffer size to the hight of the screen
+	// set buffer size to the hight of the screen, keeping the lines buffered
 	l.stream.SetBufferSize(h)
 
-	return l, nil
+	if !l.follow {
+		return l.scrollTo(l.cursor)
+	}
+	return l.renderHistory()
 }
 
-func (l logView) refreshView(logs string) (tea.Model, tea.Cmd) {
-	l.view.SetContent(logs)
-	return l, l.fetchLog()
+// refreshView - renders the new logs and waits for the next ones.
+func (l logView) refreshView(update teaLogCmd) (tea.Model, tea.Cmd) {
+	l.stats = l.stats.frame(update.lines, time.Now())
+
+	model, cmd := l.redraw()
+	l = model.(logView)
+	return l, tea.Batch(cmd, l.fetchLog())
 }
 
+// fetchLog - waits for new logs, no sooner than a frame after the last render
+// of new logs. The notifications of the stream are coalesced meanwhile, so
+// that a burst of lines is rendered once.
 func (l logView) fetchLog() tea.Cmd {
+	next := l.nextLog
+	wait := time.Until(l.stats.framed.Add(l.frame))
+
 	return func() tea.Msg {
-		<-l.nextLog
-		return teaLogCmd(l.stream.GetLive())
+		time.Sleep(wait)
+
+		lines, ok := <-next
+		if !ok {
+			return teaStreamEnd{}
+		}
+		if err := l.stream.Err(); err != nil {
+			return TeaStreamError{Err: err}
+		}
+		return teaLogCmd{lines: lines}
 	}
 }
 
@@ -117,3 +398,928 @@ func (l logView) updateSize(update TeaLogSizeUpdate) (tea.Model, tea.Cmd) {
 	l.height = update.Height
 	return l, tea.WindowSize()
 }
+
+// moveCursor - moves the cursor by `delta` rows, switching from the live
+// view to the history view if required.
+func (l logView) moveCursor(delta int) (tea.Model, tea.Cmd) {
+	if l.follow {
+		l = l.scan()
+		l.cursor = l.rows() - 1
+	}
+	return l.jumpTo(l.cursor + delta)
+}
+
+// jumpTo - shows the `row` under the cursor in the history view.
+func (l logView) jumpTo(row int) (tea.Model, tea.Cmd) {
+	l = l.scan()
+	l.follow = false
+	l.cursor = max(min(row, l.rows()-1), 0)
+	return l.scrollTo(l.cursor)
+}
+
+// jumpToLine - shows the session `line` under the cursor, or the first line
+// shown after it if it is filtered out.
+func (l logView) jumpToLine(line int) (tea.Model, tea.Cmd) {
+	l = l.scan()
+	return l.jumpTo(l.rowOf(line))
+}
+
+// scrollTo - scrolls the history view just enough for `row` to be visible.
+func (l logView) scrollTo(row int) (tea.Model, tea.Cmd) {
+	if row < l.top {
+		l.top = row
+	}
+	l.top = max(l.top, l.fitTop(row))
+	l.top = min(l.top, l.fitTop(l.rows()-1))
+
+	return l.renderHistory()
+}
+
+// fitTop - returns the first row to show for the rows up to `last` to fill
+// the view.
+func (l logView) fitTop(last int) int {
+	if last <= 0 {
+		return 0
+	}
+	if !l.wrap {
+		return max(last-l.view.Height+1, 0)
+	}
+
+	top, used := last, l.rowHeight(last)
+	for top > 0 {
+		height := l.rowHeight(top - 1)
+		if used+height > l.view.Height {
+			break
+		}
+		top -= 1
+		used += height
+	}
+	return top
+}
+
+// rowHeight - returns the lines of the view taken by the `row`.
+func (l logView) rowHeight(row int) int {
+	return l.lineHeight(l.lineAt(row))
+}
+
+// lineHeight - returns the lines of the view taken by the session `line`.
+// Heights are measured once, but in the table mode where the columns are
+// sized to the rows in view.
+func (l logView) lineHeight(line int) int {
+	if !l.wrap {
+		return 1
+	}
+
+	columns := l.textColumns(l.stream.Session().Len())
+	if height, ok := l.heights.get(line, columns); ok && !l.table {
+		return height
+	}
+
+	width := l.rowWidth(l.stream.Session().Line(line))
+	height := max((width+columns-1)/columns, 1)
+	if !l.table {
+		l.heights.set(line, columns, height)
+	}
+	return height
+}
+
+// get - returns the height of the session `line` wrapped at `columns`, if
+// measured.
+func (h *rowHeights) get(line, columns int) (int, bool) {
+	if h.columns != columns {
+		return 0, false
+	}
+	height, ok := h.lines[line]
+	return height, ok
+}
+
+// set - keeps the `height` of the session `line` wrapped at `columns`,
+// forgetting the heights at other columns.
+func (h *rowHeights) set(line, columns, height int) {
+	if h.columns != columns || h.lines == nil || len(h.lines) >= heightsKept {
+		h.columns, h.lines = columns, map[int]int{}
+	}
+	h.lines[line] = height
+}
+
+// click - moves the cursor to the row shown on the line `y` of the view,
+// counting its borders, and notifies the click.
+func (l logView) click(y int) (tea.Model, tea.Cmd) {
+	l = l.scan()
+	if l.follow {
+		l.top = l.fitTop(l.rows() - 1)
+	}
+
+	line := y - logViewStyle.GetBorderTopSize()
+	if line >= l.view.Height {
+		return l, nil
+	}
+
+	for row := l.top; row < l.rows() && line >= 0; row += 1 {
+		if line < l.rowHeight(row) {
+			model, cmd := l.jumpTo(row)
+			return model, tea.Batch(cmd, func() tea.Msg { return TeaLineClick{} })
+		}
+		line -= l.rowHeight(row)
+	}
+	return l, nil
+}
+
+// followLive - goes back to tailing the live logs, unsorted.
+func (l logView) followLive() (tea.Model, tea.Cmd) {
+	l.follow = true
+	l.anchor = -1
+	l.sortBy = ""
+	l.sorted = nil
+	return l.redraw()
+}
+
+// redraw - renders the view again.
+func (l logView) redraw() (tea.Model, tea.Cmd) {
+	return l.renderHistory()
+}
+
+func (l logView) addHighlight(rule highlight.Rule) (tea.Model, tea.Cmd) {
+	l.rules = append(l.rules[:len(l.rules):len(l.rules)], rule)
+	return l.redraw()
+}
+
+// layoutLine - splits the `line` into the parts shown in `columns` wide
+// lines, wrapped or cut at the horizontal scroll.
+func (l logView) layoutLine(line string, columns int) []string {
+	columns = max(columns, 1)
+	if !l.wrap {
+		return []string{closeStyles(ansi.Cut(line, l.xOffset, l.xOffset+columns))}
+	}
+
+	width := ansi.StringWidth(line)
+	parts := make([]string, 0, width/columns+1)
+	for from := 0; from < width || from == 0; from += columns {
+		parts = append(parts, closeStyles(ansi.Cut(line, from, from+columns)))
+	}
+	return parts
+}
+
+// renderLine - renders the `line` as shown, cut at the maximum length with a
+// marker of the bytes left out.
+func (l logView) renderLine(rules highlight.Rules, line string) string {
+	line, dropped := l.displayed(line)
+	line = rules.Apply(line)
+	if dropped > 0 {
+		line += gutterStyle.Render(fmt.Sprintf(utils.CutMarker, dropped))
+	}
+	return line
+}
+
+// lineWidth - returns the columns taken by the `line` once rendered.
+func (l logView) lineWidth(line string) int {
+	line, dropped := l.displayed(line)
+	width := ansi.StringWidth(line)
+	if dropped > 0 {
+		width += len(fmt.Sprintf(utils.CutMarker, dropped))
+	}
+	return width
+}
+
+// displayed - returns the `line` as it is shown before highlighting: with
+// its `\r` states overwritten, cut at the maximum length, sanitized, and
+// without its colors if they are stripped. Returns the bytes cut out.
+func (l logView) displayed(line string) (string, int) {
+	line, dropped := utils.CutLine(utils.ApplyCarriageReturns(line), l.maxLine)
+	line = utils.Sanitize(line)
+	if !l.colors {
+		line = utils.StripANSI(line)
+	}
+	return line, dropped
+}
+
+// closeStyles - resets the styles left open at the end of a `part` of a line,
+// so they do not run into the border.
+func closeStyles(part string) string {
+	if strings.IndexByte(part, '\x1b') < 0 {
+		return part
+	}
+	return part + "\x1b[0m"
+}
+
+// renderStyled - renders the `line` in the `style`, starting it again after
+// every reset of the line's own styles.
+func renderStyled(style lipgloss.Style, line string) string {
+	var rendered, chunk strings.Builder
+	for _, token := range utils.TokenizeANSI(line) {
+		chunk.WriteString(token.Text)
+		if token.Escape && utils.IsSGRReset(token.Text) {
+			rendered.WriteString(style.Render(chunk.String()))
+			chunk.Reset()
+		}
+	}
+
+	if chunk.Len() > 0 || rendered.Len() == 0 {
+		rendered.WriteString(style.Render(chunk.String()))
+	}
+	return rendered.String()
+}
+
+// textColumns - returns the columns left for the text of a line once the
+// bookmark marker and the gutter are drawn.
+func (l logView) textColumns(lines int) int {
+	return max(l.view.Width-1-l.gutterWidth(lines), 1)
+}
+
+// gutterWidth - returns the width of the gutter for `lines` session lines.
+func (l logView) gutterWidth(lines int) int {
+	switch {
+	case l.numbers && lines > 0:
+		return len(fmt.Sprint(lines)) + 1
+	case l.wrap:
+		return lipgloss.Width(wrapMarker) + 1
+	}
+	return 0
+}
+
+// setWrap - switches between wrapping long lines and scrolling sideways.
+func (l logView) setWrap(wrap bool) (tea.Model, tea.Cmd) {
+	l.wrap = wrap
+	l.xOffset = 0
+
+	if l.follow {
+		return l.redraw()
+	}
+	return l.scrollTo(l.cursor)
+}
+
+// scrollSideways - moves the first column shown by `delta` when not wrapping.
+func (l logView) scrollSideways(delta int) (tea.Model, tea.Cmd) {
+	if l.wrap {
+		return l, nil
+	}
+
+	l.xOffset = max(min(l.xOffset+delta, l.widest-l.columns), 0)
+	return l.redraw()
+}
+
+// showColumn - scrolls sideways just enough for the `column` to be visible.
+func (l logView) showColumn(column int) logView {
+	if l.wrap || (column >= l.xOffset && column < l.xOffset+l.columns) {
+		return l
+	}
+
+	l.xOffset = max(column-scrollStep, 0)
+	return l
+}
+
+// bottomBorder - renders the bottom border of the view with the status and
+// the wrap mode or the columns shown in it.
+func (l logView) bottomBorder() string {
+	border := logViewStyle.GetBorderStyle()
+	style := lipgloss.NewStyle().Foreground(logViewStyle.GetBorderBottomForeground())
+
+	status := l.status
+	if from, to := l.selection(); l.selecting() {
+		status = fmt.Sprintf("visual %d rows", to-from+1)
+	}
+	if len(status) == 0 {
+		status = l.unsortedText()
+	}
+	if len(status) == 0 && l.ended {
+		status = l.endText()
+	}
+	if len(status) > 0 {
+		status = " " + status + " " + border.Bottom
+	}
+
+	label := ""
+	switch {
+	case l.wrap:
+		label = " wrap "
+	case l.xOffset > 0 || l.widest > l.columns:
+		last := min(l.xOffset+l.columns, l.widest)
+		label = fmt.Sprintf(" col %d-%d/%d ", l.xOffset+1, last, l.widest)
+	}
+	if len(label)+2 > l.view.Width {
+		label = ""
+	}
+	if len(status)+len(label)+2 > l.view.Width {
+		status = ""
+	}
+
+	fill := strings.Repeat(border.Bottom, max(l.view.Width-ansi.StringWidth(status)-len(label)-1, 0))
+	return style.Render(border.BottomLeft + status + fill + label + border.Bottom + border.BottomRight)
+}
+
+// endText - returns the status shown once the stream is read, with the exit
+// code of its producer if known.
+func (l logView) endText() string {
+	if code, ok := l.stream.ExitCode(); ok {
+		return fmt.Sprintf("■ end of stream, exit code %d", code)
+	}
+	return "■ end of stream"
+}
+
+func (l logView) toggleBookmark() (tea.Model, tea.Cmd) {
+	if l.follow || l.rows() == 0 {
+		return l, nil
+	}
+
+	_, err := l.stream.Session().ToggleBookmark(l.lineAt(l.cursor), "")
+	return l, tea.Batch(
+		func() tea.Msg { return TeaBookmarksChanged{} },
+		func() tea.Msg { return TeaBookmarksSaved{Err: err} },
+	)
+}
+
+func (l logView) noteBookmark() (tea.Model, tea.Cmd) {
+	if l.follow || l.rows() == 0 {
+		return l, nil
+	}
+
+	line := l.lineAt(l.cursor)
+	return l, func() tea.Msg { return TeaBookmarkNote{Line: line} }
+}
+
+// jumpToBookmark - moves the cursor to the next bookmark, or the previous one
+// if `backward` is set.
+func (l logView) jumpToBookmark(backward bool) (tea.Model, tea.Cmd) {
+	from := l.stream.Session().Len()
+	if !l.follow && l.rows() > 0 {
+		from = l.lineAt(l.cursor)
+	}
+
+	bookmark, ok := l.stream.Session().NextBookmark(from, backward)
+	if !ok {
+		return l, nil
+	}
+	return l.jumpToLine(bookmark.Line)
+}
+
+// setFilter - shows only the records matching the `filter`, keeping the
+// line under the cursor in view.
+func (l logView) setFilter(filter cmd.Filter) (tea.Model, tea.Cmd) {
+	l.filter = filter
+	l.heights = &rowHeights{}
+	return l.reindex()
+}
+
+// toggleFold - folds the record under the cursor into its first line, or
+// unfolds it.
+func (l logView) toggleFold() (tea.Model, tea.Cmd) {
+	if l.follow || l.rows() == 0 {
+		return l, nil
+	}
+
+	start, end := l.stream.Session().Record(l.lineAt(l.cursor))
+	if end-start < 2 {
+		return l, nil
+	}
+
+	l.folds = maps.Clone(l.folds)
+	if l.folds == nil {
+		l.folds = map[int]bool{}
+	}
+	if l.folds[start] {
+		delete(l.folds, start)
+	} else {
+		l.folds[start] = true
+	}
+
+	return l.reindex()
+}
+
+// toggleFoldAll - folds every record, or unfolds them all.
+func (l logView) toggleFoldAll() (tea.Model, tea.Cmd) {
+	l.foldAll = !l.foldAll
+	l.folds = nil
+	return l.reindex()
+}
+
+// folded - reports if the record starting at `start` is folded.
+func (l logView) folded(start int) bool {
+	return l.foldAll != l.folds[start]
+}
+
+// reindex - rebuilds the rows after the filter or the folds changed, keeping
+// the line under the cursor in view.
+func (l logView) reindex() (tea.Model, tea.Cmd) {
+	line := 0
+	if !l.follow && l.rows() > 0 {
+		line = l.lineAt(l.cursor)
+	}
+
+	l.index = nil
+	l.anchor = -1
+	l.repeats = nil
+	l.repeated = false
+	l.scanned = 0
+	l, sort := l.scan().sortRows(line)
+
+	model, cmd := l.redraw()
+	if !l.follow {
+		model, cmd = l.jumpToLine(line)
+	}
+	return model, tea.Batch(cmd, sort)
+}
+
+// setSearch - highlights the matches of the `pattern` and moves to the closest
+// one.
+func (l logView) setSearch(pattern *regexp.Regexp) (tea.Model, tea.Cmd) {
+	l.search = pattern
+	if pattern == nil {
+		return l.redraw()
+	}
+
+	return l.jumpToMatch(l.follow)
+}
+
+// jumpToMatch - moves the cursor to the next row matching the search, or the
+// previous one if `backward` is set. Wraps around the ends.
+func (l logView) jumpToMatch(backward bool) (tea.Model, tea.Cmd) {
+	if l.search == nil {
+		return l, nil
+	}
+
+	l = l.scan()
+	rows := l.rows()
+
+	from := l.cursor
+	if l.follow {
+		from = rows
+	}
+
+	step := 1
+	if backward {
+		step = -1
+	}
+
+	session := l.stream.Session()
+	for i := 1; i <= rows; i += 1 {
+		row := ((from+step*i)%rows + rows) % rows
+		line, _ := l.displayed(session.Line(l.lineAt(row)))
+		line = utils.StripANSI(line)
+		if loc := l.search.FindStringIndex(line); loc != nil {
+			l = l.showColumn(ansi.StringWidth(line[:loc[0]]))
+			return l.jumpTo(row)
+		}
+	}
+
+	err := fmt.Errorf("no match for /%s/", l.search)
+	model, cmd := l.redraw()
+	return model, tea.Batch(cmd, func() tea.Msg { return TeaPromptResult{Err: err} })
+}
+
+// gotoLine - moves the cursor to the 1 based `line`.
+func (l logView) gotoLine(line int) (tea.Model, tea.Cmd) {
+	switch line {
+	case cmd.LineTop:
+		return l.jumpTo(0)
+	case cmd.LineEnd:
+		l = l.scan()
+		return l.jumpTo(l.rows() - 1)
+	}
+	return l.jumpToLine(line - 1)
+}
+
+// save - writes the lines shown, filtered or not, into the file at `path`,
+// in the order shown. Folded records are written whole.
+func (l logView) save(path string) (tea.Model, tea.Cmd) {
+	l = l.scan()
+	session := l.stream.Session()
+	rows := l.rows()
+	indexed := l.indexed() || l.sorted != nil
+
+	var lines []int
+	if indexed {
+		lines = make([]int, rows)
+		for row := range lines {
+			lines[row] = l.lineAt(row)
+		}
+	}
+
+	result := func() tea.Msg {
+		f, err := os.Create(path)
+		if err != nil {
+			return TeaPromptResult{Err: fmt.Errorf("unable to save - %w", err)}
+		}
+		defer f.Close()
+
+		saved := 0
+		for row := 0; row < rows; row += 1 {
+			from, to := row, row+1
+			if indexed {
+				from, to = lines[row], lines[row]+1
+				if start, end := session.Record(from); start == from && (row+1 == rows || lines[row+1] != to) {
+					to = end
+				}
+			}
+
+			for _, line := range session.Lines(from, to) {
+				if _, err := fmt.Fprintln(f, line); err != nil {
+					return TeaPromptResult{Err: fmt.Errorf("unable to save - %w", err)}
+				}
+				saved += 1
+			}
+		}
+
+		return TeaPromptResult{Message: fmt.Sprintf("saved %d lines to %s", saved, path)}
+	}
+
+	return l, result
+}
+
+// setOption - changes the option `name` to `value`, or reports it if the
+// `value` is nil.
+func (l logView) setOption(name string, value any) (tea.Model, tea.Cmd) {
+	if value == nil {
+		current := map[string]any{
+			cmd.OptionNumbers:  l.numbers,
+			cmd.OptionFollow:   l.follow,
+			cmd.OptionWrap:     l.wrap,
+			cmd.OptionColors:   l.colors,
+			cmd.OptionCollapse: l.collapse,
+			cmd.OptionTable:    l.table,
+			cmd.OptionCopy:     l.copyFmt,
+		}[name]
+		return l, func() tea.Msg {
+			return TeaPromptResult{Message: fmt.Sprintf("%s = %v", name, current)}
+		}
+	}
+
+	switch name {
+	case cmd.OptionNumbers:
+		l.numbers = value.(bool)
+	case cmd.OptionFollow:
+		if value.(bool) {
+			return l.followLive()
+		}
+		return l.moveCursor(0)
+	case cmd.OptionWrap:
+		return l.setWrap(value.(bool))
+	case cmd.OptionColors:
+		l.colors = value.(bool)
+		l.heights = &rowHeights{}
+	case cmd.OptionTable:
+		l.table = value.(bool)
+	case cmd.OptionCopy:
+		l.copyFmt = value.(string)
+	case cmd.OptionCollapse:
+		if l.collapse != value.(bool) {
+			l.collapse = value.(bool)
+			return l.reindex()
+		}
+	}
+
+	return l.redraw()
+}
+
+// scan - adds the rows of the records added since the last scan, keeping
+// the records matching the filter and only the first line of folded ones.
+// Records repeating the one kept before them are counted in its `repeats`
+// instead when collapsing.
+func (l logView) scan() logView {
+	if !l.indexed() {
+		return l
+	}
+
+	session := l.stream.Session()
+	total := session.Len()
+	if l.scanned >= total {
+		return l
+	}
+
+	// the last record scanned may have grown since, it is scanned again
+	from := 0
+	if l.scanned > 0 {
+		from, _ = session.Record(l.scanned - 1)
+	}
+	l.index = l.index[:sort.SearchInts(l.index, from)]
+	if l.repeated {
+		l.repeats[l.lastKept()] -= 1
+	}
+	if l.repeats == nil {
+		l.repeats = map[int]int{}
+	}
+
+	for from < total {
+		start, end := session.Record(from)
+		l.repeated = false
+		if l.matches(start, end) {
+			if l.repeatsKept(start) {
+				l.repeated = true
+				l.repeats[l.lastKept()] += 1
+			} else if end-start > 1 && l.folded(start) {
+				l.index = append(l.index, start)
+			} else {
+				for line := start; line < end; line += 1 {
+					l.index = append(l.index, line)
+				}
+			}
+		}
+		from = max(end, from+1)
+	}
+	l.scanned = total
+
+	return l
+}
+
+// matches - reports if the record within [start, end) of the session is kept
+// by the filter, if any. A record filter is matched without reading the lines.
+func (l logView) matches(start, end int) bool {
+	switch filter := l.filter.(type) {
+	case nil:
+		return true
+	case recordFilter:
+		return filter.MatchRecord(start)
+	}
+	return l.filter.Match(recordText(l.stream.Session().Lines(start, end)))
+}
+
+// repeatsKept - reports if the record starting at `start` repeats the last
+// record kept, when collapsing.
+func (l logView) repeatsKept(start int) bool {
+	if !l.collapse || len(l.index) == 0 {
+		return false
+	}
+
+	from, _, _, _ := l.stream.Session().Run(start)
+	return l.index[len(l.index)-1] >= from
+}
+
+// lastKept - returns the first line of the last record kept.
+func (l logView) lastKept() int {
+	start, _ := l.stream.Session().Record(l.index[len(l.index)-1])
+	return start
+}
+
+// recordText - returns the plain text of the `lines` of a record as given to
+// the filter.
+func recordText(lines []string) string {
+	for i, line := range lines {
+		lines[i] = plainText(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainText - returns the `line` with its `\r` states overwritten and without
+// its escape codes.
+func plainText(line string) string {
+	return utils.StripANSI(utils.ApplyCarriageReturns(line))
+}
+
+// projected - returns the session `line` as shown by the filter, the output of
+// a query projecting the records, or the line itself.
+func (l logView) projected(line string) string {
+	if projector, ok := l.filter.(cmd.Projector); ok {
+		if text, ok := projector.Project(plainText(line)); ok {
+			return text
+		}
+	}
+	return line
+}
+
+// indexed - reports if the rows are a subset of the session lines, when
+// filtering, folding or collapsing.
+func (l logView) indexed() bool {
+	return l.filter != nil || l.foldAll || len(l.folds) > 0 || l.collapse
+}
+
+// tailing - reports if the last rows of the history view are found from the
+// end of the session instead of scanning it, when following without filter,
+// folds or table. The rows are scanned once the view stops following.
+func (l logView) tailing() bool {
+	return l.follow && l.filter == nil && !l.foldAll && len(l.folds) == 0 && !l.table
+}
+
+// rows - returns the number of rows in the history view.
+func (l logView) rows() int {
+	if l.sorted != nil {
+		return len(l.sorted)
+	}
+	if l.indexed() {
+		return len(l.index)
+	}
+	return l.stream.Session().Len()
+}
+
+// lineAt - returns the session line shown at the `row`.
+func (l logView) lineAt(row int) int {
+	if l.sorted != nil {
+		return l.sorted[row]
+	}
+	if l.indexed() {
+		return l.index[row]
+	}
+	return row
+}
+
+// rowOf - returns the row showing the session `line`, the first line of its
+// record if it is folded or collapsed, or the first line shown after it.
+func (l logView) rowOf(line int) int {
+	if l.sorted != nil {
+		return l.sortedRowOf(line)
+	}
+	if !l.indexed() {
+		return line
+	}
+
+	row := sort.SearchInts(l.index, line)
+	if row > 0 && (row == len(l.index) || l.index[row] != line) {
+		session := l.stream.Session()
+		if start, _ := session.Record(line); l.index[row-1] == start {
+			return row - 1
+		}
+		if from, _, _, _ := session.Run(line); l.collapse && l.index[row-1] >= from {
+			return row - 1
+		}
+	}
+	return row
+}
+
+// sortedRowOf - returns the row showing the session `line` when sorting, or
+// that of the first line of its record if it is hidden.
+func (l logView) sortedRowOf(line int) int {
+	if row := slices.Index(l.sorted, line); row >= 0 {
+		return row
+	}
+
+	start, _ := l.stream.Session().Record(line)
+	return max(slices.Index(l.sorted, start), 0)
+}
+
+// hiddenLines - returns the lines of the record folded into the session
+// `line` shown.
+func (l logView) hiddenLines(line int) int {
+	if !l.indexed() {
+		return 0
+	}
+
+	start, end := l.stream.Session().Record(line)
+	if start != line || end-start < 2 || !l.folded(start) {
+		return 0
+	}
+	return end - start - 1
+}
+
+// repeatMarker - returns the count of the records collapsed into the record
+// at `line`, with the time the last one was seen if known.
+func (l logView) repeatMarker(line, repeats int) string {
+	marker := fmt.Sprintf(repeatMarker, repeats+1)
+	if _, _, _, last := l.stream.Session().Run(line); !last.IsZero() {
+		marker += fmt.Sprintf(repeatSeen, last.Format(time.TimeOnly))
+	}
+	return marker
+}
+
+// renderHistory - renders the rows visible from `top` in the history view,
+// prefixed by their line number and bookmark marker. Wrapped parts of a line
+// get the wrap marker instead of the number. When following the last rows are
+// shown without a cursor, read from the end of the session if tailing or else
+// from the rows scanned.
+func (l logView) renderHistory() (tea.Model, tea.Cmd) {
+	if !l.ready {
+		return l, nil
+	}
+
+	start := time.Now()
+	var (
+		lines   []int
+		repeats = l.repeats
+	)
+	if l.tailing() {
+		lines, repeats = l.tail()
+	} else {
+		l = l.scan()
+		if l.follow {
+			l.top = l.fitTop(l.rows() - 1)
+		}
+		if l.table {
+			l = l.sizeColumns(l.top)
+		}
+		for row := l.top; row < l.rows() && len(lines) < l.view.Height; row += 1 {
+			lines = append(lines, l.lineAt(row))
+		}
+	}
+
+	session := l.stream.Session()
+	marked := map[int]bool{}
+	for _, b := range session.Bookmarks() {
+		marked[b.Line] = true
+	}
+
+	rules := l.rules.WithSearch(l.search, searchStyle)
+	numWidth := l.gutterWidth(session.Len()) - 1
+	l.columns = l.textColumns(session.Len())
+	l.widest = 0
+
+	var rows []string
+	for i, n := range lines {
+		row := l.top + i
+		raw := session.Line(n)
+		l.widest = max(l.widest, l.rowWidth(raw))
+
+		mark := " "
+		if marked[n] {
+			mark = bookmarkStyle.Render(bookmarkMarker)
+		}
+
+		text := l.renderRow(rules, raw)
+		if hidden := l.hiddenLines(n); hidden > 0 {
+			text += gutterStyle.Render(fmt.Sprintf(foldMarker, hidden))
+		}
+		if repeats := repeats[n]; repeats > 0 {
+			text += gutterStyle.Render(l.repeatMarker(n, repeats))
+		}
+
+		for i, part := range l.layoutLine(text, l.columns) {
+			gutter := ""
+			switch {
+			case i > 0:
+				gutter = gutterStyle.Render(fmt.Sprintf("%*s ", numWidth, wrapMarker))
+				mark = " "
+			case l.numbers:
+				gutter = gutterStyle.Render(fmt.Sprintf("%*d ", numWidth, n+1))
+			case l.wrap:
+				gutter = strings.Repeat(" ", numWidth+1)
+			}
+
+			switch {
+			case row == l.cursor && !l.follow:
+				part = renderStyled(cursorStyle, part)
+			case l.inSelection(row):
+				part = renderStyled(selectionStyle, part)
+			}
+
+			rows = append(rows, mark+gutter+part)
+		}
+	}
+
+	if len(rows) > l.view.Height {
+		rows = rows[:l.view.Height]
+	}
+
+	l.view.SetContent(strings.Join(rows, "\n"))
+	l.stats.render = time.Since(start)
+	return l.selectLine()
+}
+
+// tail - returns the session lines of the last rows that fit in the view,
+// oldest first, and the records collapsed into them by line. The records are
+// read back from the end of the session, as many as shown, however many were
+// appended since the last render.
+func (l logView) tail() ([]int, map[int]int) {
+	session := l.stream.Session()
+	repeats := map[int]int{}
+
+	var lines []int
+	used := 0
+	for end := session.Len(); end > 0; {
+		start, stop := session.Record(end - 1)
+		if from, _, count, _ := session.Run(end - 1); l.collapse && count > 1 {
+			start, stop = session.Record(from)
+			repeats[start] = count - 1
+		}
+
+		for line := stop - 1; line >= start; line -= 1 {
+			height := l.lineHeight(line)
+			if len(lines) > 0 && used+height > l.view.Height {
+				slices.Reverse(lines)
+				return lines, repeats
+			}
+			lines = append(lines, line)
+			used += height
+		}
+		end = start
+	}
+
+	slices.Reverse(lines)
+	return lines, repeats
+}
+
+// toggleDetail - starts or stops sending the line under the cursor to the
+// detail pane. The cursor is brought up on the last row if following.
+func (l logView) toggleDetail() (tea.Model, tea.Cmd) {
+	l.detail = !l.detail
+	if !l.detail {
+		return l, nil
+	}
+
+	l.selected = -1
+	if l.follow {
+		return l.moveCursor(0)
+	}
+	return l.selectLine()
+}
+
+// selectLine - sends the line under the cursor to the detail pane, if it
+// changed since last sent.
+func (l logView) selectLine() (tea.Model, tea.Cmd) {
+	if !l.detail || l.follow || l.rows() == 0 {
+		return l, nil
+	}
+
+	line := l.lineAt(l.cursor)
+	if line == l.selected {
+		return l, nil
+	}
+
+	l.selected = line
+	return l, func() tea.Msg { return TeaLineSelect{Line: line} }
+}
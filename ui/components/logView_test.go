@@ -0,0 +1,424 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpandanBG/logctrl/config"
+	"github.com/SpandanBG/logctrl/highlight"
+	"github.com/SpandanBG/logctrl/keymap"
+	"github.com/SpandanBG/logctrl/reader"
+	ui "github.com/SpandanBG/logctrl/ui/utils"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+func init() {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+}
+
+// fakeSession - a session of lines held in memory, each line its own record
+// and run, without templates.
+type fakeSession struct {
+	lines     []string
+	bookmarks []reader.Bookmark
+}
+
+func (s *fakeSession) Append(line string) { s.lines = append(s.lines, line) }
+func (s *fakeSession) Len() int           { return len(s.lines) }
+func (s *fakeSession) Path() string       { return "fake.log" }
+func (s *fakeSession) Close()             {}
+
+func (s *fakeSession) Line(i int) string {
+	if i < 0 || i >= len(s.lines) {
+		return ""
+	}
+	return s.lines[i]
+}
+
+func (s *fakeSession) Lines(from, to int) []string {
+	from, to = max(from, 0), min(to, len(s.lines))
+	if from >= to {
+		return nil
+	}
+	return append([]string{}, s.lines[from:to]...)
+}
+
+func (s *fakeSession) ToggleBookmark(line int, note string) (bool, error) {
+	s.bookmarks = append(s.bookmarks, reader.Bookmark{Line: line, Note: note})
+	return true, nil
+}
+
+func (s *fakeSession) SetBookmarkNote(int, string) error { return nil }
+func (s *fakeSession) Bookmarks() []reader.Bookmark      { return s.bookmarks }
+
+func (s *fakeSession) NextBookmark(int, bool) (reader.Bookmark, bool) {
+	return reader.Bookmark{}, false
+}
+
+func (s *fakeSession) Record(line int) (int, int) {
+	if line < 0 || line >= len(s.lines) {
+		return line, line
+	}
+	return line, line + 1
+}
+
+func (s *fakeSession) Run(line int) (int, int, int, time.Time) {
+	from, to := s.Record(line)
+	return from, to, to - from, time.Time{}
+}
+
+func (s *fakeSession) Time(int) time.Time           { return time.Time{} }
+func (s *fakeSession) Templates() []reader.Template { return nil }
+func (s *fakeSession) MatchTemplate(string) int     { return -1 }
+func (s *fakeSession) Template(int) int             { return -1 }
+
+// fakeStream - a stream of a fake session, read to its end unless it has
+// notifications to send, its live buffer holding the last lines of the
+// session.
+type fakeStream struct {
+	session *fakeSession
+	size    int      // lines in the live buffer
+	next    chan int // notifications of the lines read, none if nil
+	err     error    // error that stopped the reading
+}
+
+func (s *fakeStream) SetBufferSize(size int)  { s.size = max(size, 1) }
+func (s *fakeStream) Session() reader.Session { return s.session }
+func (s *fakeStream) Err() error              { return s.err }
+func (s *fakeStream) ExitCode() (int, bool)   { return 0, false }
+func (s *fakeStream) Close()                  {}
+
+func (s *fakeStream) Start(context.Context) <-chan int {
+	if s.next == nil {
+		return make(chan int)
+	}
+	return s.next
+}
+
+func (s *fakeStream) GetLive() string { return strings.Join(s.Snapshot(), "\n") }
+
+func (s *fakeStream) Snapshot() []string {
+	n := s.session.Len()
+	return s.session.Lines(n-s.size, n)
+}
+
+// newTestView - returns the history view of the `lines` on a `width` x
+// `height` screen, borders included, highlighted by the `rules`.
+func newTestView(t *testing.T, lines []string, width, height int, rules ...string) logView {
+	var parsed highlight.Rules
+	for _, each := range rules {
+		rule, err := highlight.ParseRule(each)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, rule)
+	}
+
+	stream := &fakeStream{session: &fakeSession{lines: lines}}
+	model := NewLogView(context.Background(), ui.SizeRatio(1), ui.SizeRatio(1), stream, parsed, config.View{}, config.Default().Structured())
+	l := update(model, tea.WindowSizeMsg{Width: width, Height: height})
+	return update(l, teaLogCmd{lines: len(lines)}, keymap.ActionMsg{Name: keymap.Top})
+}
+
+// update - passes the `msgs` to the log view in turn.
+func update(model tea.Model, msgs ...tea.Msg) logView {
+	for _, msg := range msgs {
+		model, _ = model.Update(msg)
+	}
+	return model.(logView)
+}
+
+// rows - returns the rows of the view between its borders.
+func rows(l logView) []string {
+	lines := strings.Split(l.View(), "\n")
+	return lines[1 : len(lines)-1]
+}
+
+func Test_LogViewRowsTableDriven(t *testing.T) {
+	lines := []string{"0123456789abcdefghij", "short", "k"}
+
+	for _, test := range []struct {
+		name     string
+		msgs     []tea.Msg
+		expected []string
+	}{
+		{
+			name:     "cut at the width",
+			expected: []string{"│ 1 0123456789abcde│", "│ 2 short          │", "│ 3 k              │", "│                  │"},
+		},
+		{
+			name:     "scrolled sideways to the end of the widest line",
+			msgs:     []tea.Msg{keymap.ActionMsg{Name: keymap.ScrollRight}},
+			expected: []string{"│ 1 56789abcdefghij│", "│ 2                │", "│ 3                │", "│                  │"},
+		},
+		{
+			name:     "wrapped",
+			msgs:     []tea.Msg{keymap.ActionMsg{Name: keymap.ToggleWrap}},
+			expected: []string{"│ 1 0123456789abcde│", "│ ↪ fghij          │", "│ 2 short          │", "│ 3 k              │"},
+		},
+		{
+			name:     "wrapped without numbers",
+			msgs:     []tea.Msg{keymap.ActionMsg{Name: keymap.ToggleWrap}, TeaOptionSet{Name: "numbers", Value: false}},
+			expected: []string{"│   0123456789abcde│", "│ ↪ fghij          │", "│   short          │", "│   k              │"},
+		},
+		{
+			name:     "scrolled without numbers",
+			msgs:     []tea.Msg{TeaOptionSet{Name: "numbers", Value: false}, keymap.ActionMsg{Name: keymap.ScrollRight}},
+			expected: []string{"│ 3456789abcdefghij│", "│ rt               │", "│                  │", "│                  │"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l := update(newTestView(t, lines, 20, 6), test.msgs...)
+
+			actual := rows(l)
+			for i, row := range actual {
+				if width := ansi.StringWidth(row); width != 20 {
+					t.Errorf("expected row %d %q 20 columns wide, got %d", i, row, width)
+				}
+				actual[i] = ansi.Strip(row)
+			}
+			if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_LogViewGutterWidth(t *testing.T) {
+	lines := strings.Split(strings.Repeat("x\n", 11)+"0123456789abcdefghij", "\n")
+	l := newTestView(t, lines, 20, 6)
+	l = update(l, keymap.ActionMsg{Name: keymap.ToggleWrap})
+	for i := 0; i < 3; i += 1 {
+		l = update(l, keymap.ActionMsg{Name: keymap.PageDown})
+	}
+
+	// the numbers are aligned to the widest one, as are the wrap markers
+	expected := []string{"│ 10 x             │", "│ 11 x             │", "│ 12 0123456789abcd│", "│  ↪ efghij        │"}
+	actual := rows(l)
+	for i, row := range actual {
+		actual[i] = ansi.Strip(row)
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func Test_LogViewHighlightOffsetsTableDriven(t *testing.T) {
+	yellow, reset := "\x1b[33m", "\x1b[0m"
+	lines := []string{"cursor", "aaaaaaaaaaaaaaaaaERROR b"}
+
+	for _, test := range []struct {
+		name     string
+		msgs     []tea.Msg
+		row      int
+		expected string // highlighted part of the row
+	}{
+		{"in the wrapped part", []tea.Msg{keymap.ActionMsg{Name: keymap.ToggleWrap}}, 2, "aa" + yellow + "ERROR" + reset + " b"},
+		{"scrolled into view", []tea.Msg{keymap.ActionMsg{Name: keymap.ScrollRight}}, 1, "aaaaaaaaa" + yellow + "ERROR" + reset + " "},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l := update(newTestView(t, lines, 20, 5, `"ERROR" yellow`), test.msgs...)
+
+			row := rows(l)[test.row]
+			if !strings.Contains(row, test.expected) {
+				t.Errorf("expected %q within %q", test.expected, row)
+			}
+		})
+	}
+}
+
+func Test_LogViewResizeKeepsCursorInView(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 30; i += 1 {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	l := update(newTestView(t, lines, 20, 12), keymap.ActionMsg{Name: keymap.Top})
+	for i := 0; i < 20; i += 1 {
+		l = update(l, keymap.ActionMsg{Name: keymap.ScrollDown})
+	}
+
+	for _, test := range []struct {
+		height   int
+		expected []string // first and last rows shown
+	}{
+		{6, []string{"18 line 18", "21 line 21"}},
+		{12, []string{"18 line 18", "27 line 27"}},
+		{1, nil}, // no room for a row
+		{12, []string{"21 line 21", "30 line 30"}},
+	} {
+		l = update(l, tea.WindowSizeMsg{Width: 20, Height: test.height})
+
+		actual := rows(l)
+		if test.expected == nil {
+			continue
+		}
+
+		// the cursor stays on the same line, scrolled into view
+		for _, pair := range [][2]string{
+			{test.expected[0], ansi.Strip(actual[0])},
+			{test.expected[1], ansi.Strip(actual[len(actual)-1])},
+			{cursorStyle.Render("line 21"), strings.Join(actual, "\n")},
+		} {
+			if !strings.Contains(pair[1], pair[0]) {
+				t.Errorf("height %d: expected %q within %q", test.height, pair[0], pair[1])
+			}
+		}
+	}
+}
+
+func Test_LogViewResizeReflowsWrappedLines(t *testing.T) {
+	lines := []string{"0123456789abcdefghijklmnopqrstuvwxyz", "end"}
+	l := update(newTestView(t, lines, 20, 7), keymap.ActionMsg{Name: keymap.ToggleWrap})
+
+	for _, test := range []struct {
+		width    int
+		expected []string
+	}{
+		{20, []string{"│ 1 0123456789abcde│", "│ ↪ fghijklmnopqrst│", "│ ↪ uvwxyz         │", "│ 2 end            │", "│                  │"}},
+		{30, []string{"│ 1 0123456789abcdefghijklmno│", "│ ↪ pqrstuvwxyz              │", "│ 2 end                      │", "│                            │", "│                            │"}},
+		{14, []string{"│ 1 012345678│", "│ ↪ 9abcdefgh│", "│ ↪ ijklmnopq│", "│ ↪ rstuvwxyz│", "│ 2 end      │"}},
+	} {
+		l = update(l, tea.WindowSizeMsg{Width: test.width, Height: 7})
+
+		actual := rows(l)
+		for i, row := range actual {
+			actual[i] = ansi.Strip(row)
+		}
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("width %d: expected %q, got %q", test.width, test.expected, actual)
+		}
+	}
+}
+
+// messages - runs the `cmd` and returns the messages of its batch.
+func messages(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+
+	var msgs []tea.Msg
+	for _, each := range batch {
+		msgs = append(msgs, messages(each)...)
+	}
+	return msgs
+}
+
+func Test_LogViewSortInBackground(t *testing.T) {
+	lines := []string{`{"n":3}`, `{"n":1}`, `{"n":2}`}
+	l := newTestView(t, lines, 40, 6)
+
+	model, cmd := l.Update(TeaSortSet{Field: "n"})
+	l = model.(logView)
+	if actual := ansi.Strip(rows(l)[0]); !strings.Contains(actual, `{"n":3}`) {
+		t.Errorf("expected the rows unsorted while sorting, got %q", actual)
+	}
+
+	// the rows are taken when sorting starts, lines read since are left out
+	session := l.stream.Session().(*fakeSession)
+	session.Append(`{"n":0}`)
+	l = update(l, messages(cmd)...)
+
+	actual := rows(l)
+	for i, row := range actual {
+		actual[i] = ansi.Strip(row)
+	}
+	expected := []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}
+	for i, line := range expected {
+		if !strings.Contains(actual[i], line) {
+			t.Errorf("expected row %d %q within %q", i, line, actual[i])
+		}
+	}
+
+	note := "1 new lines not sorted"
+	if border := ansi.Strip(l.bottomBorder()); !strings.Contains(border, note) {
+		t.Errorf("expected %q within %q", note, border)
+	}
+}
+
+func Test_LogViewStreamError(t *testing.T) {
+	stream := &fakeStream{session: &fakeSession{}, next: make(chan int, 1)}
+	model := NewLogView(context.Background(), ui.SizeRatio(1), ui.SizeRatio(1), stream, nil, config.View{}, nil)
+	l := update(model, tea.WindowSizeMsg{Width: 30, Height: 5})
+
+	// lines are read, then the reading fails and the stream ends
+	stream.session.Append("a")
+	stream.session.Append("b")
+	stream.err = errors.New("read failed")
+	stream.next <- 2
+	close(stream.next)
+
+	// the messages of each update are passed on until none are left
+	for msgs := messages(l.fetchLog()); len(msgs) > 0; {
+		var next []tea.Msg
+		for _, msg := range msgs {
+			model, cmd := l.Update(msg)
+			l, next = model.(logView), append(next, messages(cmd)...)
+		}
+		msgs = next
+	}
+
+	if !l.ended {
+		t.Fatal("expected the end of the stream after the read error")
+	}
+	actual := rows(l)
+	for i, line := range []string{"a", "b"} {
+		if !strings.Contains(ansi.Strip(actual[i]), line) {
+			t.Errorf("expected row %d %q within %q", i, line, actual[i])
+		}
+	}
+	if border := ansi.Strip(l.bottomBorder()); !strings.Contains(border, "end of stream") {
+		t.Errorf("expected the end of the stream within %q", border)
+	}
+}
+
+func Test_LogViewFollowTableDriven(t *testing.T) {
+	lines := []string{"a", "b", "retry 1", "retry 2", "0123456789abcdefghij"}
+
+	// the last rows are shown, with line numbers, however the lines are indexed
+	for _, test := range []struct {
+		name     string
+		msgs     []tea.Msg
+		expected []string
+	}{
+		{
+			name:     "collapsing",
+			expected: []string{"│ 2 b              │", "│ 3 retry 1        │", "│ 4 retry 2        │", "│ 5 0123456789abcde│"},
+		},
+		{
+			name:     "not collapsing",
+			msgs:     []tea.Msg{TeaOptionSet{Name: "collapse", Value: false}},
+			expected: []string{"│ 2 b              │", "│ 3 retry 1        │", "│ 4 retry 2        │", "│ 5 0123456789abcde│"},
+		},
+		{
+			name:     "wrapped",
+			msgs:     []tea.Msg{TeaOptionSet{Name: "collapse", Value: false}, keymap.ActionMsg{Name: keymap.ToggleWrap}},
+			expected: []string{"│ 3 retry 1        │", "│ 4 retry 2        │", "│ 5 0123456789abcde│", "│ ↪ fghij          │"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l := newTestView(t, lines, 20, 6)
+			l = update(l, append(test.msgs, TeaOptionSet{Name: "follow", Value: true})...)
+
+			actual := rows(l)
+			for i, row := range actual {
+				actual[i] = ansi.Strip(row)
+			}
+			if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package components
+
+// ----- Public tea.Msg
+
+// TeaMouseClick - a left click at the position within the pane it is sent to
+type TeaMouseClick struct {
+	X int
+	Y int
+}
+
+// TeaMouseWheel - the wheel turned over the pane it is sent to, by notches
+// down or up if negative
+type TeaMouseWheel struct {
+	Delta int
+}
+
+// TeaLineClick - notifies that a line of the log view was clicked
+type TeaLineClick struct{}
+
+// TeaActionClick - asks to run the action of a clicked toolbar item
+type TeaActionClick struct {
+	Name string
+}
+
+const wheelStep = 3 // rows moved by a notch of the wheel
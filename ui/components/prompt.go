@@ -1,9 +1,15 @@
 package components
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/input"
+	"github.com/SpandanBG/logctrl/keymap"
 	ui "github.com/SpandanBG/logctrl/ui/utils"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // ----- Public tea.Msg
@@ -11,21 +17,62 @@ type TeaPromptToggle struct {
 	BringFocus bool
 }
 
+// TeaPromptSubmit - carries the text submitted from the prompt
+type TeaPromptSubmit struct {
+	Text string
+}
+
+// TeaPromptResult - the outcome of the last submitted text, shown above the
+// prompt
+type TeaPromptResult struct {
+	Message string
+	Err     error
+}
+
+// TeaPromptSearch - notifies that the history search started or ended
+type TeaPromptSearch struct {
+	Active bool
+}
+
+var (
+	promptErrorStyle = lipgloss.NewStyle().
+				Foreground(ui.Red_Color)
+	promptMessageStyle = lipgloss.NewStyle().
+				Foreground(ui.Grey_Color)
+)
+
 type prompt struct {
-	width    ui.SizeI
-	height   ui.SizeI
-	size     tea.WindowSizeMsg
-	rendered string
-	view     textarea.Model
-	focused  bool
+	width      ui.SizeI
+	height     ui.SizeI
+	size       tea.WindowSizeMsg
+	rendered   string
+	view       textarea.Model
+	focused    bool
+	result     TeaPromptResult
+	history    input.History
+	completer  input.Completer
+	position   int      // history entry shown, `history.Len()` for the draft
+	draft      string   // text typed before browsing or searching the history
+	searching  bool     // if `true` keys edit the history search query
+	query      string   // history search query
+	match      int      // history entry matching the query
+	failing    bool     // if `true` nothing matches the query
+	candidates []string // completions left after the last tab
 }
 
-func NewPrompt(width, height ui.SizeI) tea.Model {
+func NewPrompt(
+	width, height ui.SizeI,
+	history input.History,
+	completer input.Completer,
+) tea.Model {
 	return &prompt{
-		width:   width,
-		height:  height,
-		view:    textarea.New(),
-		focused: false,
+		width:     width,
+		height:    height,
+		view:      textarea.New(),
+		focused:   false,
+		history:   history,
+		completer: completer,
+		position:  history.Len(),
 	}
 }
 
@@ -41,6 +88,13 @@ func (p prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return p.updateView(msg)
 	case TeaPromptToggle:
 		return p.setFocus(msg)
+	case TeaPromptResult:
+		p.result = msg
+		return p, nil
+	case keymap.ActionMsg:
+		return p.executeAction(msg.Name)
+	case tea.KeyMsg:
+		return p.receiveKey(msg)
 	}
 
 	var cmd tea.Cmd
@@ -49,17 +103,175 @@ func (p prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (p prompt) View() string {
-	return p.view.View()
+	result := promptMessageStyle.Render(p.result.Message)
+	if p.result.Err != nil {
+		result = promptErrorStyle.Render(p.result.Err.Error())
+	}
+
+	return result + "\n" + promptMessageStyle.Render(p.hint()) + "\n" + p.view.View()
 }
 
 // ------------------------- Private
 func (p prompt) updateView(size tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	p.size = ui.ModifySize(size, p.width, p.height)
 	p.view.SetWidth(p.size.Width)
-	p.view.SetHeight(p.size.Height)
+	p.view.SetHeight(p.size.Height - 2)
+	return p, nil
+}
+
+func (p prompt) executeAction(action string) (tea.Model, tea.Cmd) {
+	switch action {
+	case keymap.Submit:
+		if p.searching {
+			return p.endSearch(true)
+		}
+		return p.submit()
+	case keymap.ClosePrompt:
+		return p.endSearch(false)
+	case keymap.HistoryPrev:
+		return p.browse(-1)
+	case keymap.HistoryNext:
+		return p.browse(1)
+	case keymap.HistorySearch:
+		return p.search()
+	case keymap.Complete:
+		return p.complete()
+	}
+	return p, nil
+}
+
+// receiveKey - edits the history search query while searching, or the text
+// otherwise. Other keys end the search and keep the match.
+func (p prompt) receiveKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var searchCmd tea.Cmd
+
+	if p.searching {
+		switch msg.Type {
+		case tea.KeyRunes, tea.KeySpace:
+			p.query += string(msg.Runes)
+			return p.findMatch(p.history.Len() - 1)
+		case tea.KeyBackspace:
+			if n := len([]rune(p.query)); n > 0 {
+				p.query = string([]rune(p.query)[:n-1])
+			}
+			return p.findMatch(p.history.Len() - 1)
+		}
+
+		var model tea.Model
+		model, searchCmd = p.endSearch(true)
+		p = model.(prompt)
+	}
+
+	var cmd tea.Cmd
+	p.candidates = nil
+	p.view, cmd = p.view.Update(msg)
+	return p, tea.Batch(searchCmd, cmd)
+}
+
+// hint - returns the line shown above the text: the history search, the
+// completions left or the syntax of the command typed.
+func (p prompt) hint() string {
+	switch {
+	case p.searching && p.failing:
+		return fmt.Sprintf("failing history search: %s", p.query)
+	case p.searching:
+		return fmt.Sprintf("history search: %s", p.query)
+	case len(p.candidates) > 0:
+		return strings.Join(p.candidates, "  ")
+	}
+	return input.Hint(p.view.Value())
+}
+
+// browse - shows the history entry `delta` entries away, the draft being
+// after the newest one.
+func (p prompt) browse(delta int) (tea.Model, tea.Cmd) {
+	if p.searching {
+		return p, nil
+	}
+
+	last := p.history.Len()
+	if p.position >= last {
+		p.position = last
+		p.draft = p.view.Value()
+	}
+
+	p.position = max(min(p.position+delta, last), 0)
+	p.candidates = nil
+
+	if p.position == last {
+		p.view.SetValue(p.draft)
+	} else {
+		p.view.SetValue(p.history.At(p.position))
+	}
+	return p, nil
+}
+
+// search - starts the history search, or moves to the next older match if it
+// is already going on.
+func (p prompt) search() (tea.Model, tea.Cmd) {
+	if p.searching {
+		return p.findMatch(p.match - 1)
+	}
+
+	p.searching = true
+	p.failing = false
+	p.query = ""
+	p.draft = p.view.Value()
+	p.match = p.history.Len()
+	p.candidates = nil
+
+	return p, func() tea.Msg { return TeaPromptSearch{Active: true} }
+}
+
+// findMatch - shows the newest history entry at or before `from` matching the
+// query.
+func (p prompt) findMatch(from int) (tea.Model, tea.Cmd) {
+	i, ok := p.history.Search(p.query, from)
+	p.failing = !ok
+	if ok {
+		p.match = i
+		p.view.SetValue(p.history.At(i))
+	}
 	return p, nil
 }
 
+// endSearch - ends the history search, keeping the match if `accept` is set
+// or going back to the draft otherwise.
+func (p prompt) endSearch(accept bool) (tea.Model, tea.Cmd) {
+	if !p.searching {
+		return p, nil
+	}
+
+	p.searching = false
+	if !accept || p.match >= p.history.Len() {
+		p.view.SetValue(p.draft)
+	}
+
+	return p, func() tea.Msg { return TeaPromptSearch{Active: false} }
+}
+
+// complete - completes the last word of the text.
+func (p prompt) complete() (tea.Model, tea.Cmd) {
+	if p.searching {
+		return p, nil
+	}
+
+	var text string
+	text, p.candidates = p.completer.Complete(p.view.Value())
+	p.view.SetValue(text)
+	return p, nil
+}
+
+func (p prompt) submit() (tea.Model, tea.Cmd) {
+	text := p.view.Value()
+	p.view.Reset()
+	p.history.Add(text)
+	p.position = p.history.Len()
+	p.candidates = nil
+
+	return p, func() tea.Msg { return TeaPromptSubmit{Text: text} }
+}
+
 func (p prompt) setFocus(data TeaPromptToggle) (tea.Model, tea.Cmd) {
 	p.focused = data.BringFocus
 
@@ -0,0 +1,141 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/SpandanBG/logctrl/cmd"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ----- Public tea.Msg
+
+// TeaClipboardSet - asks to set the clipboard of the terminal to the text
+type TeaClipboardSet struct {
+	Text string
+}
+
+// clipboardLimit - bytes copied at most, terminals drop longer OSC 52
+// sequences
+const clipboardLimit = 64 * 1024
+
+var (
+	selectionStyle = lipgloss.NewStyle().
+		Background(lipgloss.Color("238"))
+)
+
+// ------------------------- Private
+
+// selecting - reports if lines are being selected.
+func (l logView) selecting() bool {
+	return l.anchor >= 0 && !l.follow
+}
+
+// selection - returns the range [from, to] of the rows selected, or the row
+// under the cursor when not selecting.
+func (l logView) selection() (int, int) {
+	if !l.selecting() {
+		return l.cursor, l.cursor
+	}
+	return min(l.anchor, l.cursor), max(l.anchor, l.cursor)
+}
+
+// inSelection - reports if the `row` is within the selection.
+func (l logView) inSelection(row int) bool {
+	from, to := l.selection()
+	return l.selecting() && row >= from && row <= to
+}
+
+// toggleSelect - starts selecting lines from the row under the cursor, or
+// stops selecting.
+func (l logView) toggleSelect() (tea.Model, tea.Cmd) {
+	if l.selecting() {
+		l.anchor = -1
+		return l.redraw()
+	}
+
+	if l.follow {
+		l = l.scan()
+		l.cursor = l.rows() - 1
+		l.follow = false
+	}
+	l.anchor = max(l.cursor, 0)
+	return l.jumpTo(l.cursor)
+}
+
+// yank - copies the selected lines, or the line under the cursor, to the
+// clipboard and stops selecting. Folded records are copied whole.
+func (l logView) yank() (tea.Model, tea.Cmd) {
+	if l.follow || l.rows() == 0 {
+		return l, nil
+	}
+
+	l = l.scan()
+	from, to := l.selection()
+	session := l.stream.Session()
+
+	var lines []int
+	for row := from; row <= to; row += 1 {
+		line := l.lineAt(row)
+		end := line + 1
+		if start, stop := session.Record(line); start == line && (row+1 == l.rows() || l.lineAt(row+1) != end) {
+			end = stop
+		}
+		for ; line < end; line += 1 {
+			lines = append(lines, line)
+		}
+	}
+
+	text := l.copyText(lines)
+	l.anchor = -1
+	if len(text) > clipboardLimit {
+		l.status = fmt.Sprintf("not copied, %d bytes over the %d bytes limit", len(text)-clipboardLimit, clipboardLimit)
+		return l.redraw()
+	}
+	l.status = fmt.Sprintf("copied %d lines as %s", len(lines), l.copyFmt)
+
+	model, cmd := l.redraw()
+	return model, tea.Batch(cmd, func() tea.Msg { return TeaClipboardSet{Text: text} })
+}
+
+// copyText - returns the session `lines` in the copy format. The JSON format
+// holds a record per item, its JSON object if it is one or else its text.
+func (l logView) copyText(lines []int) string {
+	session := l.stream.Session()
+
+	if l.copyFmt != cmd.CopyJSON {
+		texts := make([]string, len(lines))
+		for i, line := range lines {
+			texts[i] = session.Line(line)
+			if l.copyFmt == cmd.CopyText {
+				texts[i] = plainText(texts[i])
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+
+	var records []any
+	for i := 0; i < len(lines); {
+		start, _ := session.Record(lines[i])
+
+		var texts []string
+		for ; i < len(lines); i += 1 {
+			if s, _ := session.Record(lines[i]); s != start || (len(texts) > 0 && lines[i] != lines[i-1]+1) {
+				break
+			}
+			texts = append(texts, plainText(session.Line(lines[i])))
+		}
+
+		record := strings.TrimSpace(strings.Join(texts, "\n"))
+		if strings.HasPrefix(record, "{") && json.Valid([]byte(record)) {
+			records = append(records, json.RawMessage(record))
+		} else {
+			records = append(records, record)
+		}
+	}
+
+	text, _ := json.MarshalIndent(records, "", "  ")
+	return string(text)
+}
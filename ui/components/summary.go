@@ -0,0 +1,237 @@
+package components
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SpandanBG/logctrl/cmd"
+	"github.com/SpandanBG/logctrl/keymap"
+	"github.com/SpandanBG/logctrl/reader"
+	ui "github.com/SpandanBG/logctrl/ui/utils"
+	"github.com/SpandanBG/logctrl/utils"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ----- Public tea.Msg
+type TeaSummaryToggle struct {
+	BringFocus bool
+}
+
+// TeaTemplateSelect - asks to show only the records of a template in the log
+// view
+type TeaTemplateSelect struct {
+	Filter cmd.Filter
+}
+
+const summaryRow = "%7d  %-8s  %-8s  %s"
+
+var (
+	summaryStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), true, false, false, false).
+		BorderForeground(lipgloss.Color("63"))
+)
+
+type summary struct {
+	width    ui.SizeI
+	height   ui.SizeI
+	size     tea.WindowSizeMsg
+	session  reader.Session    // session holding the templates
+	list     []reader.Template // templates as last read from the session
+	selected int               // index of the selected template in `list`
+	sample   string            // first line of the selected template
+	byCount  bool              // if `true` the most frequent templates come first
+}
+
+func NewSummary(width, height ui.SizeI, session reader.Session) tea.Model {
+	return summary{
+		width:   width,
+		height:  height,
+		session: session,
+		byCount: true,
+	}
+}
+
+func (s summary) Init() tea.Cmd {
+	return tea.Batch(
+		tea.WindowSize(),
+	)
+}
+
+func (s summary) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case keymap.ActionMsg:
+		return s.executeAction(msg.Name)
+	case tea.WindowSizeMsg:
+		s.size = ui.ModifySize(msg, s.width, s.height)
+		return s, nil
+	case TeaSummaryToggle:
+		if msg.BringFocus {
+			s.selected = 0
+			return s.reload()
+		}
+	case TeaMouseWheel:
+		return s.choose(s.selected + msg.Delta), nil
+	case TeaMouseClick:
+		return s.click(msg.Y)
+	}
+
+	return s, nil
+}
+
+func (s summary) View() string {
+	order := "by order of appearance"
+	if s.byCount {
+		order = "by frequency"
+	}
+
+	rows := []string{
+		bookmarksTitleStyle.Render(fmt.Sprintf("Templates (%d) %s", len(s.list), order)),
+		gutterStyle.Render(fmt.Sprintf("%7s  %-8s  %-8s  %s", "count", "first", "last", "template")),
+	}
+
+	start, visible := s.shown(len(rows))
+	for i := start; i < len(s.list) && i < start+visible; i += 1 {
+		t := s.list[i]
+		row := fmt.Sprintf(summaryRow, t.Count, seen(t.First, t.FirstSeen), seen(t.Last, t.LastSeen), utils.Sanitize(t.Text))
+		row = ansi.Truncate(row, s.size.Width, "…")
+		if i == s.selected {
+			row = cursorStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(s.list) > 0 {
+		rows = append(rows, ansi.Truncate(gutterStyle.Render("sample: ")+s.sample, s.size.Width, "…"))
+	}
+
+	return summaryStyle.
+		Width(s.size.Width).
+		Height(s.size.Height - summaryStyle.GetVerticalFrameSize()).
+		Render(strings.Join(rows, "\n"))
+}
+
+// ------------------------- Private
+func (s summary) executeAction(action string) (tea.Model, tea.Cmd) {
+	// the templates keep changing while the logs are read
+	model, _ := s.reload()
+	s = model.(summary)
+
+	switch action {
+	case keymap.ScrollUp:
+		return s.choose(s.selected - 1), nil
+	case keymap.ScrollDown:
+		return s.choose(s.selected + 1), nil
+	case keymap.SortTemplates:
+		s.byCount = !s.byCount
+		return s.reload()
+	case keymap.FilterTemplate:
+		return s.filter()
+	}
+
+	return s, nil
+}
+
+// reload - reads the templates again from the session, keeping the selected
+// one selected.
+func (s summary) reload() (tea.Model, tea.Cmd) {
+	id := -1
+	if s.selected < len(s.list) {
+		id = s.list[s.selected].ID
+	}
+
+	s.list = s.session.Templates()
+	if s.byCount {
+		slices.SortStableFunc(s.list, func(a, b reader.Template) int {
+			return cmp.Compare(b.Count, a.Count)
+		})
+	}
+
+	for i, t := range s.list {
+		if t.ID == id {
+			s.selected = i
+		}
+	}
+
+	return s.choose(s.selected), nil
+}
+
+// choose - selects the template at `i`, kept within the list, and reads its
+// first line as the sample shown.
+func (s summary) choose(i int) summary {
+	s.selected = max(min(i, len(s.list)-1), 0)
+	s.sample = ""
+	if len(s.list) > 0 {
+		s.sample = utils.StripANSI(utils.Sanitize(s.session.Line(s.list[s.selected].First)))
+	}
+	return s
+}
+
+// shown - returns the first template shown below the `header` rows and how
+// many fit, keeping the selected one in view and leaving a row for its sample.
+func (s summary) shown(header int) (int, int) {
+	visible := max(s.size.Height-summaryStyle.GetVerticalFrameSize()-header-1, 0)
+	return max(s.selected-visible+1, 0), visible
+}
+
+// click - selects the template on the row `y` of the pane, or filters by it
+// if it was already selected.
+func (s summary) click(y int) (tea.Model, tea.Cmd) {
+	header := 2
+	start, visible := s.shown(header)
+	row := y - summaryStyle.GetBorderTopSize() - header
+	if row < 0 || row >= visible || start+row >= len(s.list) {
+		return s, nil
+	}
+
+	if start+row == s.selected {
+		return s.filter()
+	}
+	return s.choose(start + row), nil
+}
+
+// filter - asks to show only the records of the selected template.
+func (s summary) filter() (tea.Model, tea.Cmd) {
+	if len(s.list) == 0 {
+		return s, nil
+	}
+
+	filter := templateFilter{session: s.session, template: s.list[s.selected]}
+	return s, func() tea.Msg { return TeaTemplateSelect{Filter: filter} }
+}
+
+// seen - returns when a template was seen, the time if known or else the
+// line.
+func seen(line int, at time.Time) string {
+	if at.IsZero() {
+		return fmt.Sprintf("#%d", line+1)
+	}
+	return at.Format(time.TimeOnly)
+}
+
+// templateFilter - shows the records whose first line belongs to a template.
+type templateFilter struct {
+	session  reader.Session
+	template reader.Template
+}
+
+// Match - reports if the first line of the `record` belongs to the template
+// as the templates are now. The log view matches by MatchRecord instead.
+func (f templateFilter) Match(record string) bool {
+	line, _, _ := strings.Cut(record, "\n")
+	return f.session.MatchTemplate(line) == f.template.ID
+}
+
+// MatchRecord - reports if the record starting at the session line `start`
+// was added to the template when it was read.
+func (f templateFilter) MatchRecord(start int) bool {
+	return f.session.Template(start) == f.template.ID
+}
+
+func (f templateFilter) String() string {
+	return "template " + f.template.Text
+}
@@ -0,0 +1,49 @@
+package components
+
+import (
+	"github.com/SpandanBG/logctrl/config"
+	"github.com/SpandanBG/logctrl/highlight"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SetTheme - restyles the components with the colors of the `theme`. Must be
+// called before the UI is created. Colors that fail to parse are left as is,
+// the config is validated before reaching here.
+func SetTheme(theme config.Theme) {
+	color := func(name string, apply func(lipgloss.Color)) {
+		if c, err := highlight.ParseColor(name); err == nil {
+			apply(c)
+		}
+	}
+
+	color(theme.Border, func(c lipgloss.Color) {
+		logViewStyle = logViewStyle.BorderForeground(c)
+		bookmarksStyle = bookmarksStyle.BorderForeground(c)
+		summaryStyle = summaryStyle.BorderForeground(c)
+		detailStyle = detailStyle.BorderForeground(c)
+	})
+	color(theme.ToolbarBg, func(c lipgloss.Color) {
+		toolbarStyle = toolbarStyle.Background(c)
+		helpKeyStyle = helpKeyStyle.Background(c)
+		helpTitleStyle = helpTitleStyle.Background(c)
+		toolbarErrorStyle = toolbarErrorStyle.Background(c)
+	})
+	color(theme.ToolbarFg, func(c lipgloss.Color) {
+		toolbarStyle = toolbarStyle.Foreground(c)
+	})
+	color(theme.ToolbarKey, func(c lipgloss.Color) {
+		helpKeyStyle = helpKeyStyle.Foreground(c)
+	})
+	color(theme.Gutter, func(c lipgloss.Color) {
+		gutterStyle = gutterStyle.Foreground(c)
+		helpTitleStyle = helpTitleStyle.Foreground(c)
+		promptMessageStyle = promptMessageStyle.Foreground(c)
+	})
+	color(theme.Bookmark, func(c lipgloss.Color) {
+		bookmarkStyle = bookmarkStyle.Foreground(c)
+	})
+	color(theme.Error, func(c lipgloss.Color) {
+		promptErrorStyle = promptErrorStyle.Foreground(c)
+		toolbarErrorStyle = toolbarErrorStyle.Foreground(c)
+	})
+}
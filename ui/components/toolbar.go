@@ -1,31 +1,64 @@
 package components
 
 import (
+	"github.com/SpandanBG/logctrl/keymap"
 	ui "github.com/SpandanBG/logctrl/ui/utils"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
-const helpText = ui.Grey_Color + "Quick Help:\t\t" +
-	ui.Magenta_Color + "q" + ui.Black_Color + ":Quit" +
-	ui.Reset_Color
+// ----- Public tea.Msg
+
+// TeaStreamError - reports the error that stopped the reading of the logs
+type TeaStreamError struct {
+	Err error
+}
+
+// TeaBookmarksSaved - reports the outcome of saving the bookmarks changed,
+// nil once saved
+type TeaBookmarksSaved struct {
+	Err error
+}
 
 var (
 	toolbarStyle = lipgloss.NewStyle().
-		Background(lipgloss.Color("43")).
-		Foreground(lipgloss.Color("0"))
+			Background(lipgloss.Color("43")).
+			Foreground(ui.Black_Color)
+	helpTitleStyle = toolbarStyle.
+			Foreground(ui.Grey_Color)
+	helpKeyStyle = toolbarStyle.
+			Foreground(ui.Magenta_Color)
+	toolbarErrorStyle = toolbarStyle.
+				Foreground(ui.Red_Color).
+				Bold(true)
 )
 
+const helpTitle = "Quick Help:        "
+
+// helpActions - actions listed in the quick help
+var helpActions = []string{
+	keymap.Quit,
+	keymap.TogglePrompt,
+	keymap.Bookmark,
+	keymap.ToggleBookmarks,
+}
+
 type toolbar struct {
 	width    ui.SizeI
 	height   ui.SizeI
+	size     tea.WindowSizeMsg
 	rendered string
+	keys     keymap.Keymap
+	err      error // error that stopped the reading of the logs
+	saveErr  error // error saving the bookmarks last changed
 }
 
-func NewToolbar(width, height ui.SizeI) tea.Model {
+func NewToolbar(width, height ui.SizeI, keys keymap.Keymap) tea.Model {
 	return toolbar{
 		width:  width,
 		height: height,
+		keys:   keys,
 	}
 }
 
@@ -39,6 +72,14 @@ func (t toolbar) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		return t.updateView(msg)
+	case TeaStreamError:
+		t.err = msg.Err
+		return t.render()
+	case TeaBookmarksSaved:
+		t.saveErr = msg.Err
+		return t.render()
+	case TeaMouseClick:
+		return t.click(msg.X)
 	}
 
 	return t, nil
@@ -50,12 +91,64 @@ func (t toolbar) View() string {
 
 // ------------------------- Private
 func (t toolbar) updateView(size tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
-	size = ui.ModifySize(size, t.width, t.height)
+	t.size = ui.ModifySize(size, t.width, t.height)
+	return t.render()
+}
+
+// render - renders the quick help, after the errors if there are any.
+func (t toolbar) render() (tea.Model, tea.Cmd) {
+	text := t.errorText() + t.helpText()
 
 	t.rendered = toolbarStyle.
-		Width(size.Width).
-		Height(size.Height).
-		Render(helpText)
+		Width(t.size.Width).
+		Height(t.size.Height).
+		MaxHeight(t.size.Height).
+		Render(text)
 
 	return t, nil
 }
+
+// errorText - returns the read error and the bookmarks save error, if there
+// are any.
+func (t toolbar) errorText() string {
+	text := ""
+	if t.err != nil {
+		text += toolbarErrorStyle.Render("read error: " + t.err.Error() + "  ")
+	}
+	if t.saveErr != nil {
+		text += toolbarErrorStyle.Render("bookmarks not saved: " + t.saveErr.Error() + "  ")
+	}
+	return text
+}
+
+// helpText - lists the help actions with the first key bound to each.
+func (t toolbar) helpText() string {
+	text := helpTitleStyle.Render(helpTitle)
+	for _, name := range helpActions {
+		action, _ := keymap.Lookup(name)
+		if keys := t.keys.Keys(name); len(keys) > 0 {
+			text += helpKeyStyle.Render(keys[0]) +
+				toolbarStyle.Render(":"+action.Help+"  ")
+		}
+	}
+	return text
+}
+
+// click - asks to run the action of the help item at the column `x`.
+func (t toolbar) click(x int) (tea.Model, tea.Cmd) {
+	from := ansi.StringWidth(t.errorText() + helpTitle)
+	for _, name := range helpActions {
+		action, _ := keymap.Lookup(name)
+		keys := t.keys.Keys(name)
+		if len(keys) == 0 {
+			continue
+		}
+
+		to := from + ansi.StringWidth(keys[0]+":"+action.Help)
+		if x >= from && x < to {
+			return t, func() tea.Msg { return TeaActionClick{Name: name} }
+		}
+		from = to + 2
+	}
+	return t, nil
+}
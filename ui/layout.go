@@ -1,8 +1,14 @@
 package ui
 
 import (
+	"context"
+	"os"
 	"strings"
 
+	"github.com/SpandanBG/logctrl/cmd"
+	"github.com/SpandanBG/logctrl/config"
+	"github.com/SpandanBG/logctrl/input"
+	"github.com/SpandanBG/logctrl/keymap"
 	"github.com/SpandanBG/logctrl/reader"
 	"github.com/SpandanBG/logctrl/ui/components"
 	ui "github.com/SpandanBG/logctrl/ui/utils"
@@ -12,7 +18,7 @@ import (
 
 const (
 	toolbarSize = 1
-	promptSize  = 16
+	fieldSample = 200 // last lines looked at to complete field names
 )
 
 var (
@@ -23,34 +29,108 @@ var (
 
 type logTeaCmd string
 
+// pane - a part of the screen, stacked by the layout
+type pane int
+
+const (
+	noPane pane = iota
+	toolbarPane
+	logViewPane
+	detailPane
+	bookmarksPane
+	summaryPane
+	promptPane
+)
+
 type uiModel struct {
-	toolbar      tea.Model
-	logView      tea.Model
-	prompt       tea.Model
-	promptActive bool
+	toolbar         tea.Model
+	logView         tea.Model
+	bookmarks       tea.Model
+	summary         tea.Model
+	detail          tea.Model
+	prompt          tea.Model
+	promptActive    bool
+	bookmarksActive bool
+	summaryActive   bool
+	detailActive    bool // the line under the log view cursor is detailed
+	noteActive      bool // a bookmark note is being typed
+	searchActive    bool // the prompt history is being searched
+	promptSize      int
+	bookmarksSize   int
+	summarySize     int
+	detailSize      int
+	height          int // height of the screen
+	keys            keymap.Keymap
+	executor        cmd.Executor
+	out             *output // the terminal drawn on
 }
 
-func NewUI(stream reader.Stream) (
+func NewUI(ctx context.Context, stream reader.Stream, cfg config.Config) (
 	app *tea.Program,
 	exit func(),
 ) {
+	components.SetTheme(cfg.Theme)
+	keys := cfg.Keymap()
+
+	// an unreadable history file leaves the history in memory only
+	history, _ := input.NewHistory(input.HistoryPath())
+	out := &output{File: os.Stdout}
+	parsers := cfg.Structured()
+	fields := func() []string {
+		session := stream.Session()
+		n := session.Len()
+		return input.Fields(session.Lines(max(n-fieldSample, 0), n), parsers)
+	}
+
 	app = tea.NewProgram(
 		uiModel{
 			toolbar: components.NewToolbar(
 				ui.SizeRatio(1),
 				ui.SizeFixed(toolbarSize),
+				keys,
 			),
 			logView: components.NewLogView(
+				ctx,
 				ui.SizeRatio(1),
 				ui.SizeModifier(-toolbarSize),
 				stream,
+				cfg.Rules(),
+				cfg.View,
+				parsers,
+			),
+			bookmarks: components.NewBookmarks(
+				ui.SizeRatio(1),
+				ui.SizeFixed(cfg.Layout.BookmarksHeight),
+				stream.Session(),
+			),
+			summary: components.NewSummary(
+				ui.SizeRatio(1),
+				ui.SizeFixed(cfg.Layout.SummaryHeight),
+				stream.Session(),
+			),
+			detail: components.NewDetail(
+				ui.SizeRatio(1),
+				ui.SizeFixed(cfg.Layout.DetailHeight),
+				stream.Session(),
+				parsers,
 			),
 			prompt: components.NewPrompt(
 				ui.SizeRatio(1),
-				ui.SizeFixed(promptSize),
+				ui.SizeFixed(cfg.Layout.PromptHeight),
+				history,
+				input.NewCompleter(fields, cfg.Filters),
 			),
+			promptSize:    cfg.Layout.PromptHeight,
+			bookmarksSize: cfg.Layout.BookmarksHeight,
+			summarySize:   cfg.Layout.SummaryHeight,
+			detailSize:    cfg.Layout.DetailHeight,
+			keys:          keys,
+			executor:      cmd.NewExecutor(cfg.Filters),
+			out:           out,
 		},
+		tea.WithOutput(out),
 		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
 	)
 
 	exit = func() {
@@ -65,6 +145,9 @@ func (u uiModel) Init() tea.Cmd {
 	return tea.Batch(
 		u.toolbar.Init(),
 		u.logView.Init(),
+		u.bookmarks.Init(),
+		u.summary.Init(),
+		u.detail.Init(),
 		u.prompt.Init(),
 	)
 }
@@ -72,10 +155,35 @@ func (u uiModel) Init() tea.Cmd {
 func (u uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if u.promptActive {
-			return u.receivePrompt(msg)
+		return u.receiveKey(msg)
+	case tea.MouseMsg:
+		return u.receiveMouse(msg)
+	case tea.WindowSizeMsg:
+		u.height = msg.Height
+	case components.TeaLineClick:
+		if !u.detailActive {
+			return u.toggleDetail()
 		}
-		return u.executeKeystroke(msg.String())
+		return u, nil
+	case components.TeaActionClick:
+		return u.executeAction(msg.Name)
+	case components.TeaBookmarkNote:
+		return u.noteBookmark(msg)
+	case components.TeaBookmarkNoteDone:
+		u.noteActive = false
+		return u.batchUpdate(components.TeaBookmarksChanged{})
+	case components.TeaBookmarkJump:
+		return u.jumpToBookmark(msg)
+	case components.TeaTemplateSelect:
+		return u.filterTemplate(msg)
+	case components.TeaClipboardSet:
+		u.out.setClipboard(msg.Text)
+		return u, nil
+	case components.TeaPromptSubmit:
+		return u.executePrompt(msg.Text)
+	case components.TeaPromptSearch:
+		u.searchActive = msg.Active
+		return u, nil
 	}
 
 	return u.batchUpdate(msg)
@@ -86,58 +194,213 @@ func (u uiModel) View() string {
 }
 
 // ------------------------- Private
-func (u uiModel) executeKeystroke(key string) (tea.Model, tea.Cmd) {
-	switch key {
-	case "ctrl+c", "ctrl+d", "q":
-		return u, tea.Quit
-	case "tab":
-		return u.togglePrompt()
+
+// receiveKey - resolves the key in the context of the focused pane. The bound
+// action is run, or the key itself is passed on to the focused pane if it is
+// not bound.
+func (u uiModel) receiveKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var (
+		action   string
+		consumed bool
+	)
+
+	u.keys, action, consumed = u.keys.Resolve(u.context(), msg.String())
+	if !consumed {
+		return u.updateFocused(msg)
+	}
+
+	if len(action) == 0 {
+		// waiting for the rest of a key sequence
+		return u, nil
+	}
+
+	return u.executeAction(action)
+}
+
+// receiveMouse - passes the wheel turns and the left clicks to the pane under
+// the mouse, at a position relative to the pane.
+func (u uiModel) receiveMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress {
+		return u, nil
+	}
+
+	target, top := u.paneAt(msg.Y)
+
+	var event tea.Msg
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		event = components.TeaMouseWheel{Delta: -1}
+	case tea.MouseButtonWheelDown:
+		event = components.TeaMouseWheel{Delta: 1}
+	case tea.MouseButtonLeft:
+		event = components.TeaMouseClick{X: msg.X, Y: msg.Y - top}
 	default:
 		return u, nil
 	}
+
+	var cmd tea.Cmd
+	switch target {
+	case toolbarPane:
+		u.toolbar, cmd = u.toolbar.Update(event)
+	case logViewPane:
+		u.logView, cmd = u.logView.Update(event)
+	case bookmarksPane:
+		u.bookmarks, cmd = u.bookmarks.Update(event)
+	case summaryPane:
+		u.summary, cmd = u.summary.Update(event)
+	case detailPane:
+		u.detail, cmd = u.detail.Update(event)
+	}
+
+	return u, cmd
 }
 
-func (u uiModel) receivePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "esc":
+// paneAt - returns the pane shown on the screen line `y`, with the line it
+// starts on.
+func (u uiModel) paneAt(y int) (pane, int) {
+	stack := []struct {
+		pane   pane
+		height int
+		shown  bool
+	}{
+		{toolbarPane, toolbarSize, true},
+		{logViewPane, u.height - u.panelsHeight(), true},
+		{detailPane, u.detailSize, u.detailActive},
+		{bookmarksPane, u.bookmarksSize, u.bookmarksActive},
+		{summaryPane, u.summarySize, u.summaryActive},
+		{promptPane, u.promptSize, u.promptActive},
+	}
+
+	top := 0
+	for _, p := range stack {
+		if !p.shown {
+			continue
+		}
+		if y < top+p.height {
+			return p.pane, top
+		}
+		top += p.height
+	}
+	return noPane, top
+}
+
+func (u uiModel) executeAction(action string) (tea.Model, tea.Cmd) {
+	switch action {
+	case keymap.Quit:
+		return u, tea.Quit
+	case keymap.TogglePrompt:
 		return u.togglePrompt()
-	default:
+	case keymap.ToggleBookmarks, keymap.CloseBookmarks:
+		return u.toggleBookmarks()
+	case keymap.ToggleSummary, keymap.CloseSummary:
+		return u.toggleSummary()
+	case keymap.ToggleDetail:
+		return u.toggleDetail()
+	case keymap.ScrollDetailUp, keymap.ScrollDetailDown:
 		var cmd tea.Cmd
-		u.prompt, cmd = u.prompt.Update(msg)
+		u.detail, cmd = u.detail.Update(keymap.ActionMsg{Name: action})
 		return u, cmd
+	case keymap.ClosePrompt:
+		if !u.noteActive && !u.searchActive {
+			return u.togglePrompt()
+		}
+	}
+
+	return u.updateFocused(keymap.ActionMsg{Name: action})
+}
+
+// context - returns the keymap context of the focused pane.
+func (u uiModel) context() keymap.Context {
+	switch {
+	case u.promptActive, u.noteActive:
+		return keymap.Prompt
+	case u.bookmarksActive:
+		return keymap.Bookmarks
+	case u.summaryActive:
+		return keymap.Summary
+	default:
+		return keymap.LogView
 	}
 }
 
+// updateFocused - passes the `msg` to the focused pane only.
+func (u uiModel) updateFocused(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch {
+	case u.promptActive:
+		u.prompt, cmd = u.prompt.Update(msg)
+	case u.bookmarksActive:
+		u.bookmarks, cmd = u.bookmarks.Update(msg)
+	case u.summaryActive:
+		u.summary, cmd = u.summary.Update(msg)
+	default:
+		u.logView, cmd = u.logView.Update(msg)
+	}
+
+	return u, cmd
+}
+
+// executePrompt - runs the command submitted from the prompt and reports the
+// outcome back to it.
+func (u uiModel) executePrompt(text string) (tea.Model, tea.Cmd) {
+	target := &commandTarget{}
+	message, err := u.executor.Execute(text, target)
+
+	cmds := target.cmds()
+	if err != nil || len(message) > 0 {
+		result := components.TeaPromptResult{Message: message, Err: err}
+		cmds = append(cmds, func() tea.Msg { return result })
+	}
+
+	// in order, the outcome shown once the components got the command
+	return u, tea.Sequence(cmds...)
+}
+
 func (u uiModel) batchUpdate(msg tea.Msg) (tea.Model, tea.Cmd) {
 	updateCount := u.getUpdateCount()
 	cmds := make([]tea.Cmd, updateCount)
 
 	u.toolbar, cmds[0] = u.toolbar.Update(msg)
 	u.logView, cmds[1] = u.logView.Update(msg)
+	u.bookmarks, cmds[2] = u.bookmarks.Update(msg)
+	u.summary, cmds[3] = u.summary.Update(msg)
+	u.detail, cmds[4] = u.detail.Update(msg)
 
 	if u.promptActive {
-		u.prompt, cmds[2] = u.prompt.Update(msg)
+		u.prompt, cmds[5] = u.prompt.Update(msg)
 	}
 
 	return u, tea.Batch(cmds...)
 }
 
 func (u uiModel) batchView() string {
-	updateCount := u.getUpdateCount()
-	views := make([]string, updateCount)
+	views := []string{
+		u.toolbar.View(),
+		u.logView.View(),
+	}
+
+	if u.detailActive {
+		views = append(views, u.detail.View())
+	}
 
-	views[0] = u.toolbar.View()
-	views[1] = u.logView.View()
+	if u.bookmarksActive {
+		views = append(views, u.bookmarks.View())
+	}
+
+	if u.summaryActive {
+		views = append(views, u.summary.View())
+	}
 
 	if u.promptActive {
-		views[2] = u.prompt.View()
+		views = append(views, u.prompt.View())
 	}
 
 	return strings.Join(views, "\n")
 }
 
 func (u uiModel) getUpdateCount() int {
-	updateCount := 2
+	updateCount := 5
 	if u.promptActive {
 		updateCount += 1
 	}
@@ -148,24 +411,125 @@ func (u uiModel) getUpdateCount() int {
 func (u uiModel) togglePrompt() (tea.Model, tea.Cmd) {
 	u.promptActive = !u.promptActive
 
-	modifier := toolbarSize
-	if u.promptActive {
-		modifier += promptSize
-	}
+	var promptCmd tea.Cmd
+	u.prompt, promptCmd = u.prompt.Update(components.TeaPromptToggle{
+		BringFocus: u.promptActive,
+	})
+
+	return u, tea.Batch(
+		u.resizeLogView(),
+		promptCmd,
+	)
+}
 
-	var logViewCmd, promptCmd tea.Cmd
+func (u uiModel) toggleBookmarks() (tea.Model, tea.Cmd) {
+	u.bookmarksActive = !u.bookmarksActive
+	u.noteActive = false
 
-	u.logView, logViewCmd = u.logView.Update(components.TeaLogSizeUpdate{
-		Width:  ui.SizeRatio(1),
-		Height: ui.SizeModifier(-modifier),
+	var bookmarksCmd tea.Cmd
+	u.bookmarks, bookmarksCmd = u.bookmarks.Update(components.TeaBookmarksToggle{
+		BringFocus: u.bookmarksActive,
 	})
 
-	u.prompt, promptCmd = u.prompt.Update(components.TeaPromptToggle{
-		BringFocus: u.promptActive,
+	return u, tea.Batch(
+		u.resizeLogView(),
+		bookmarksCmd,
+	)
+}
+
+func (u uiModel) toggleSummary() (tea.Model, tea.Cmd) {
+	u.summaryActive = !u.summaryActive
+
+	var summaryCmd tea.Cmd
+	u.summary, summaryCmd = u.summary.Update(components.TeaSummaryToggle{
+		BringFocus: u.summaryActive,
 	})
 
 	return u, tea.Batch(
+		u.resizeLogView(),
+		summaryCmd,
+	)
+}
+
+// toggleDetail - opens or closes the detail pane below the log view. The log
+// view keeps the focus and sends the line under its cursor to the pane.
+func (u uiModel) toggleDetail() (tea.Model, tea.Cmd) {
+	u.detailActive = !u.detailActive
+
+	var logViewCmd tea.Cmd
+	u.logView, logViewCmd = u.logView.Update(keymap.ActionMsg{Name: keymap.ToggleDetail})
+
+	return u, tea.Batch(
+		u.resizeLogView(),
 		logViewCmd,
-		promptCmd,
 	)
 }
+
+// noteBookmark - opens the bookmarks panel to take the note of a bookmark.
+func (u uiModel) noteBookmark(msg components.TeaBookmarkNote) (tea.Model, tea.Cmd) {
+	var toggleCmd, noteCmd tea.Cmd
+	if !u.bookmarksActive {
+		var model tea.Model
+		model, toggleCmd = u.toggleBookmarks()
+		u = model.(uiModel)
+	}
+
+	u.noteActive = true
+	u.bookmarks, noteCmd = u.bookmarks.Update(msg)
+	return u, tea.Batch(toggleCmd, noteCmd)
+}
+
+// jumpToBookmark - closes the bookmarks panel and moves the log view to the
+// bookmarked line.
+func (u uiModel) jumpToBookmark(msg components.TeaBookmarkJump) (tea.Model, tea.Cmd) {
+	model, toggleCmd := u.toggleBookmarks()
+	u = model.(uiModel)
+
+	var jumpCmd tea.Cmd
+	u.logView, jumpCmd = u.logView.Update(msg)
+	return u, tea.Batch(toggleCmd, jumpCmd)
+}
+
+// filterTemplate - closes the summary panel and shows only the records of the
+// selected template in the log view.
+func (u uiModel) filterTemplate(msg components.TeaTemplateSelect) (tea.Model, tea.Cmd) {
+	model, toggleCmd := u.toggleSummary()
+	u = model.(uiModel)
+
+	var filterCmd tea.Cmd
+	u.logView, filterCmd = u.logView.Update(components.TeaFilterSet{Filter: msg.Filter})
+	return u, tea.Batch(toggleCmd, filterCmd)
+}
+
+// resizeLogView - makes the log view take the height left by the toolbar and
+// the panels that are currently open.
+func (u uiModel) resizeLogView() tea.Cmd {
+	modifier := u.panelsHeight()
+
+	return func() tea.Msg {
+		return components.TeaLogSizeUpdate{
+			Width:  ui.SizeRatio(1),
+			Height: ui.SizeModifier(-modifier),
+		}
+	}
+}
+
+// panelsHeight - returns the height taken by the toolbar and the panels that
+// are currently open.
+func (u uiModel) panelsHeight() int {
+	modifier := toolbarSize
+	if u.promptActive {
+		modifier += u.promptSize
+	}
+	if u.bookmarksActive {
+		modifier += u.bookmarksSize
+	}
+	if u.summaryActive {
+		modifier += u.summarySize
+	}
+	if u.detailActive {
+		modifier += u.detailSize
+	}
+
+	return modifier
+}
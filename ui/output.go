@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// output - the terminal the program draws on. Its writes are serialized, so
+// that a sequence written besides the frames, like that of the clipboard,
+// never lands within one. Being a file the program still sizes the terminal
+// from it.
+type output struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (o *output) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.File.Write(b)
+}
+
+// ------------------------- Private
+
+// setClipboard - sets the clipboard of the terminal with an OSC 52 sequence,
+// which works over SSH too. The sequence is passed through tmux and screen
+// when running within them.
+func (o *output) setClipboard(text string) {
+	seq := osc52.New(text)
+	switch {
+	case len(os.Getenv("TMUX")) > 0:
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+
+	// nothing can be drawn either once the output fails
+	seq.WriteTo(o)
+}
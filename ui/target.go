@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"regexp"
+
+	"github.com/SpandanBG/logctrl/cmd"
+	"github.com/SpandanBG/logctrl/highlight"
+	"github.com/SpandanBG/logctrl/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commandTarget - collects the messages the executed commands send to the
+// components.
+type commandTarget struct {
+	msgs []tea.Msg
+}
+
+func (t *commandTarget) Filter(filter cmd.Filter) {
+	t.send(components.TeaFilterSet{Filter: filter})
+}
+
+func (t *commandTarget) Search(pattern *regexp.Regexp) {
+	t.send(components.TeaSearchSet{Pattern: pattern})
+}
+
+func (t *commandTarget) Goto(line int) {
+	t.send(components.TeaGoto{Line: line})
+}
+
+func (t *commandTarget) Save(path string) {
+	t.send(components.TeaSave{Path: path})
+}
+
+func (t *commandTarget) Highlight(rule highlight.Rule) {
+	t.send(components.TeaHighlightAdd{Rule: rule})
+}
+
+func (t *commandTarget) Set(name string, value any) {
+	t.send(components.TeaOptionSet{Name: name, Value: value})
+}
+
+func (t *commandTarget) Columns(fields []string) {
+	t.send(components.TeaColumnsSet{Fields: fields})
+}
+
+func (t *commandTarget) Sort(field string, descending bool) {
+	t.send(components.TeaSortSet{Field: field, Descending: descending})
+}
+
+// ------------------------- Private
+
+// cmds - returns a command for each message collected, in order, to be run
+// in sequence so that the components get them in that order.
+func (t *commandTarget) cmds() []tea.Cmd {
+	cmds := make([]tea.Cmd, len(t.msgs))
+	for i, msg := range t.msgs {
+		cmds[i] = func() tea.Msg { return msg }
+	}
+	return cmds
+}
+
+func (t *commandTarget) send(msg tea.Msg) {
+	t.msgs = append(t.msgs, msg)
+}
@@ -1,14 +1,15 @@
 package utils
 
+import "github.com/charmbracelet/lipgloss"
+
 const (
-	Black_Color   = "\x1b[30m"
-	Grey_Color    = "\x1b[90m"
-	Red_Color     = "\x1b[31m"
-	Green_Color   = "\x1b[32m"
-	Yellow_Color  = "\x1b[33m"
-	Blue_Color    = "\x1b[34m"
-	Magenta_Color = "\x1b[35m"
-	Cyan_Color    = "\x1b[36m"
-	White_Color   = "\x1b[37m"
-	Reset_Color   = "\x1b[39m" // foreground-only reset
+	Black_Color   = lipgloss.Color("0")
+	Red_Color     = lipgloss.Color("1")
+	Green_Color   = lipgloss.Color("2")
+	Yellow_Color  = lipgloss.Color("3")
+	Blue_Color    = lipgloss.Color("4")
+	Magenta_Color = lipgloss.Color("5")
+	Cyan_Color    = lipgloss.Color("6")
+	White_Color   = lipgloss.Color("7")
+	Grey_Color    = lipgloss.Color("8")
 )
@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	esc = '\x1b'
+	bel = '\x07'
+	del = '\x7f'
+
+	invalidPlaceholder = "\uFFFD"
+	tabSpaces          = "    "
+)
+
+// AnsiToken - a chunk of a line that is either printable text or a single
+// escape sequence.
+type AnsiToken struct {
+	Text   string
+	Escape bool
+}
+
+// TokenizeANSI - splits the `line` into text and escape sequence tokens. CSI
+// (`ESC [ ... final`), OSC (`ESC ] ... BEL|ST`) and two byte escapes are
+// recognized. An unterminated sequence at the end of the line is returned as
+// an escape token.
+func TokenizeANSI(line string) []AnsiToken {
+	var tokens []AnsiToken
+
+	start := 0
+	for i := 0; i < len(line); {
+		if line[i] != esc {
+			i += 1
+			continue
+		}
+
+		if start < i {
+			tokens = append(tokens, AnsiToken{Text: line[start:i]})
+		}
+
+		end := escapeEnd(line, i)
+		tokens = append(tokens, AnsiToken{Text: line[i:end], Escape: true})
+		i, start = end, end
+	}
+
+	if start < len(line) {
+		tokens = append(tokens, AnsiToken{Text: line[start:]})
+	}
+
+	return tokens
+}
+
+// StripANSI - removes all escape sequences from the `line`.
+func StripANSI(line string) string {
+	if strings.IndexByte(line, esc) < 0 {
+		return line
+	}
+
+	var plain strings.Builder
+	for _, token := range TokenizeANSI(line) {
+		if !token.Escape {
+			plain.WriteString(token.Text)
+		}
+	}
+	return plain.String()
+}
+
+// Sanitize - makes the `line` safe to print in the terminal. SGR sequences are
+// kept, any other escape sequence is shown as text (`^[[2J`). Control bytes
+// are shown in caret notation (`^G`), C1 controls as `<U+009B>`, invalid
+// UTF-8 as U+FFFD and tabs as spaces.
+func Sanitize(line string) string {
+	if isPrintable(line) {
+		return line
+	}
+
+	var safe strings.Builder
+	for _, token := range TokenizeANSI(line) {
+		if token.Escape && IsSGR(token.Text) {
+			safe.WriteString(token.Text)
+			continue
+		}
+		writeVisible(&safe, token.Text)
+	}
+	return safe.String()
+}
+
+// ApplyCarriageReturns - returns the `line` as a terminal would show it, the
+// text after each `\r` overwriting the line from its first column. Characters
+// overwrite by the cells they take on the screen, a wide character partly
+// overwritten leaving a space. Escape sequences stay in front of the
+// character that follows them.
+func ApplyCarriageReturns(line string) string {
+	if strings.IndexByte(line, '\r') < 0 {
+		return line
+	}
+
+	var (
+		cells   []string        // characters shown by cell, "" right of a wide one
+		pending strings.Builder // escapes waiting for the next character
+		column  int
+		last    = -1 // cell of the last character written
+	)
+
+	for _, token := range TokenizeANSI(line) {
+		if token.Escape {
+			pending.WriteString(token.Text)
+			continue
+		}
+
+		for i := 0; i < len(token.Text); {
+			_, size := utf8.DecodeRuneInString(token.Text[i:])
+			char := token.Text[i : i+size]
+			i += size
+
+			if char == "\r" {
+				column = 0
+				continue
+			}
+
+			cell := pending.String() + char
+			pending.Reset()
+
+			width := ansi.StringWidth(char)
+			if width == 0 && last >= 0 {
+				// combining characters join the character before them
+				cells[last] += cell
+				continue
+			}
+			width = max(width, 1)
+
+			for len(cells) < column+width {
+				cells = append(cells, " ")
+			}
+			for c := column; c < column+width; c += 1 {
+				clearWide(cells, c)
+			}
+
+			cells[column] = cell
+			for c := column + 1; c < column+width; c += 1 {
+				cells[c] = ""
+			}
+			last, column = column, column+width
+		}
+	}
+
+	return strings.Join(cells, "") + pending.String()
+}
+
+// IsSGR - reports if the escape sequence sets graphic rendition (colors and
+// text attributes), i.e. is of the form `ESC [ <params> m`.
+func IsSGR(seq string) bool {
+	if len(seq) < 3 || seq[0] != esc || seq[1] != '[' || seq[len(seq)-1] != 'm' {
+		return false
+	}
+
+	for _, c := range seq[2 : len(seq)-1] {
+		if (c < '0' || c > '9') && c != ';' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSGRReset - reports if the escape sequence resets all graphic rendition.
+func IsSGRReset(seq string) bool {
+	return seq == "\x1b[m" || seq == "\x1b[0m"
+}
+
+// ----------------------- PRIVATE
+
+// isPrintable - reports if the `line` is valid UTF-8 without control bytes.
+func isPrintable(line string) bool {
+	for i := 0; i < len(line); i += 1 {
+		if c := line[i]; c < 0x20 || c == del || c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// clearWide - blanks the other half of the wide character in the cell `c`
+// about to be overwritten, if there is one.
+func clearWide(cells []string, c int) {
+	switch {
+	case cells[c] == "":
+		cells[c-1] = " "
+	case c+1 < len(cells) && cells[c+1] == "":
+		cells[c+1] = " "
+	}
+}
+
+// writeVisible - writes the `text` with its control bytes and invalid UTF-8
+// replaced by visible placeholders.
+func writeVisible(safe *strings.Builder, text string) {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			safe.WriteString(invalidPlaceholder)
+		case r == '\t':
+			safe.WriteString(tabSpaces)
+		case r < 0x20:
+			safe.WriteByte('^')
+			safe.WriteByte(byte(r) + '@')
+		case r == del:
+			safe.WriteString("^?")
+		case r >= 0x80 && r < 0xa0:
+			fmt.Fprintf(safe, "<U+%04X>", r)
+		default:
+			safe.WriteString(text[i : i+size])
+		}
+		i += size
+	}
+}
+
+// escapeEnd - returns the index right after the escape sequence starting at
+// `i` within the `line`.
+func escapeEnd(line string, i int) int {
+	if i+1 >= len(line) {
+		return len(line)
+	}
+
+	switch line[i+1] {
+	case '[':
+		// CSI: parameter and intermediate bytes up to a final byte
+		for j := i + 2; j < len(line); j += 1 {
+			if line[j] >= 0x40 && line[j] <= 0x7e {
+				return j + 1
+			}
+		}
+		return len(line)
+	case ']', 'P', '_', '^', 'X':
+		// OSC and other strings: terminated by BEL or ST (ESC \)
+		for j := i + 2; j < len(line); j += 1 {
+			if line[j] == bel {
+				return j + 1
+			}
+			if line[j] == esc && j+1 < len(line) && line[j+1] == '\\' {
+				return j + 2
+			}
+		}
+		return len(line)
+	default:
+		// the byte after ESC may start a multi byte character
+		_, size := utf8.DecodeRuneInString(line[i+1:])
+		return i + 1 + size
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import "testing"
+
+func Test_SanitizeTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{"plain", "hello world", "hello world"},
+		{"unicode", "héllo ✓", "héllo ✓"},
+		{"sgr kept", "\x1b[31mred\x1b[0m", "\x1b[31mred\x1b[0m"},
+		{"clear screen", "a\x1b[2Jb", "a^[[2Jb"},
+		{"cursor move", "\x1b[10;5Hx", "^[[10;5Hx"},
+		{"alternate screen", "\x1b[?1049h", "^[[?1049h"},
+		{"osc title", "\x1b]0;pwned\x07ok", "^[]0;pwned^Gok"},
+		{"two byte escape", "\x1bcreset", "^[creset"},
+		{"escape before unicode", "\x1bé!", "^[é!"},
+		{"unterminated", "x\x1b[31", "x^[[31"},
+		{"control bytes", "a\x00b\x07c\x7f", "a^@b^Gc^?"},
+		{"carriage return", "50%\r100%", "50%^M100%"},
+		{"tab", "a\tb", "a    b"},
+		{"c1 control", "a\u009bb", "a<U+009B>b"},
+		{"invalid utf8", "a\xffb\xc3", "a�b�"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := Sanitize(test.line); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_StripANSI(t *testing.T) {
+	line := "\x1b[1;31merror\x1b[0m: \x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\"
+	if actual := StripANSI(line); actual != "error: link" {
+		t.Errorf("expected %q, got %q", "error: link", actual)
+	}
+}
+
+func Test_ApplyCarriageReturnsTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{"no carriage return", "plain", "plain"},
+		{"progress", "10%\r50%\r100%", "100%"},
+		{"shorter overwrite", "downloading\rdone", "doneloading"},
+		{"trailing", "[####]\r", "[####]"},
+		{"leading", "\rready", "ready"},
+		{"colors", "\x1b[32m50%\x1b[0m\r\x1b[32m100%\x1b[0m", "\x1b[0m\x1b[32m100%\x1b[0m"},
+		{"unicode", "█░░\r██", "██░"},
+		{"wide overwritten", "日本\rab", "ab本"},
+		{"wide half overwritten", "日本\rabc", "abc "},
+		{"wide over narrow", "abc\r日", "日c"},
+		{"wide over wide half", "a日\r本", "本 "},
+		{"combining", "cafe\u0301!\rCAFE", "CAFE!"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := ApplyCarriageReturns(test.line); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
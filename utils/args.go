@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SplitArgs - splits the `line` into whitespace separated arguments. Arguments
+// can be wrapped in single or double quotes to keep whitespace within them.
+// Backslashes are kept as is so that regular expressions can be written
+// without doubling them, except for `\"` (or `\'`) within a quoted argument
+// which stands for the quote itself.
+func SplitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i += 1 {
+		r := runes[i]
+
+		switch {
+		case quote != 0 && r == '\\' && i+1 < len(runes) && runes[i+1] == quote:
+			current.WriteRune(quote)
+			i += 1
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("missing closing quote %c", quote)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
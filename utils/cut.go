@@ -0,0 +1,20 @@
+package utils
+
+import "unicode/utf8"
+
+// CutMarker - marks a line cut by `CutLine`, formatted with the bytes left out.
+const CutMarker = " [+%d bytes]"
+
+// CutLine - cuts the `line` at `limit` bytes, on a rune boundary. Returns the
+// bytes left out. A `limit` of 0 or less leaves the line whole.
+func CutLine(line string, limit int) (string, int) {
+	if limit <= 0 || len(line) <= limit {
+		return line, 0
+	}
+
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut -= 1
+	}
+	return line[:cut], len(line) - cut
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func Test_CutLineTableDriven(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		line     string
+		limit    int
+		expected string
+		dropped  int
+	}{
+		{"no limit", "hello", 0, "hello", 0},
+		{"within the limit", "hello", 5, "hello", 0},
+		{"cut", "hello world", 5, "hello", 6},
+		{"on a rune boundary", "héllo", 2, "h", 5},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			actual, dropped := CutLine(test.line, test.limit)
+			if actual != test.expected || dropped != test.dropped {
+				t.Errorf("expected %q and %d bytes dropped, got %q and %d", test.expected, test.dropped, actual, dropped)
+			}
+		})
+	}
+}